@@ -3,9 +3,10 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
-	"path/filepath"
+	"strconv"
 
 	"github.com/spf13/cobra"
 
@@ -27,7 +28,26 @@ var (
 	outputType string
 	outputPath string
 	podName    string
+	project    string
 	noWarning  bool
+	dryRun     bool
+	showDiff   bool
+	prune      bool
+	verify     bool
+	quadletBin string
+	envFile    string
+	envPass    bool
+	autoUpdate string
+	imageUnits bool
+	authFile   string
+	tlsVerify  optionalBool
+	exitCode   string
+)
+
+var (
+	installUser  bool
+	installRoot  bool
+	installStart bool
 )
 
 func main() {
@@ -41,9 +61,10 @@ var rootCmd = &cobra.Command{
 	Short: "Convert Docker Compose files to Podman formats",
 	Long: `compose2podman converts Docker Compose files to Podman-compatible formats.
 
-Supports two output formats:
+Supports three output formats:
   - Kubernetes YAML for 'podman play kube'
   - Podman Quadlet files for systemd integration
+  - Kubernetes YAML run under systemd by a Quadlet .kube unit
 
 ⚠️  WARNING: This is a PROOF-OF-CONCEPT tool generated by GitHub Copilot.
    NOT tested with real data. NOT intended for production use.
@@ -53,12 +74,47 @@ Supports two output formats:
 	SilenceUsage: true,
 }
 
+var installCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Install Quadlet units and reload systemd",
+	Long: `Generate Quadlet units directly into the Quadlet unit directory and run
+systemctl daemon-reload so systemd picks them up.
+
+Rootless units go to $XDG_CONFIG_HOME/containers/systemd (default
+~/.config/containers/systemd), rootful units to /etc/containers/systemd.
+Use --output to install into a different directory, and --type kube-quadlet
+to install the services as one pod run by a .kube unit.`,
+	RunE:         runInstall,
+	SilenceUsage: true,
+}
+
 func init() {
 	rootCmd.PersistentFlags().StringVarP(&inputFile, "input", "i", "", "Path to docker-compose file (auto-detects if not specified)")
-	rootCmd.PersistentFlags().StringVarP(&outputType, "type", "t", "kube", "Output type: kube or quadlet")
+	rootCmd.PersistentFlags().StringVarP(&outputType, "type", "t", "kube", "Output type: kube, quadlet or kube-quadlet (kube YAML run by a .kube unit)")
 	rootCmd.PersistentFlags().StringVarP(&outputPath, "output", "o", "", "Output file (kube) or directory (quadlet)")
-	rootCmd.PersistentFlags().StringVarP(&podName, "pod-name", "p", "compose-pod", "Pod name for Kubernetes output")
+	rootCmd.PersistentFlags().StringVarP(&project, "project-name", "p", "", "Project name (default: $COMPOSE_PROJECT_NAME, top-level name: or the compose file directory)")
+	rootCmd.PersistentFlags().StringVar(&podName, "pod-name", "", "Pod name for Kubernetes output (default: project name)")
 	rootCmd.PersistentFlags().BoolVarP(&noWarning, "quiet", "q", false, "Suppress proof-of-concept warning")
+	rootCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "Print what would be written without changing the output directory (quadlet)")
+	rootCmd.PersistentFlags().BoolVar(&showDiff, "diff", false, "Print a unified diff against existing files in the output directory (quadlet)")
+	rootCmd.PersistentFlags().BoolVar(&prune, "prune", false, "Remove previously generated files that are no longer produced (quadlet)")
+
+	rootCmd.PersistentFlags().StringVar(&envFile, "env-file-mode", string(types.EnvFileInline), "How to convert env_file: inline (copy values) or reference (EnvironmentFile= / ConfigMap envFrom)")
+	rootCmd.PersistentFlags().BoolVar(&envPass, "env-passthrough", false, "Pass environment variables declared without a value through from the unit environment at run time instead of resolving them now (quadlet)")
+	rootCmd.PersistentFlags().StringVar(&autoUpdate, "auto-update", "", "Enable podman auto-update for services without x-podman.auto-update: registry or local")
+	rootCmd.PersistentFlags().BoolVar(&imageUnits, "image-units", false, "Generate a .image unit per distinct image so images are pulled once before containers start (quadlet)")
+	rootCmd.PersistentFlags().StringVar(&authFile, "authfile", "", "Registry auth file written to the .image units (quadlet)")
+	rootCmd.PersistentFlags().Var(&tlsVerify, "tls-verify", "Require HTTPS and verify certificates when pulling .image units (quadlet)")
+	rootCmd.PersistentFlags().Lookup("tls-verify").NoOptDefVal = "true"
+	rootCmd.PersistentFlags().StringVar(&exitCode, "exit-code-propagation", "", "How container exit codes fail the .kube unit: all, any or none (kube-quadlet)")
+	rootCmd.PersistentFlags().BoolVar(&verify, "verify", false, "Verify generated Quadlet files with quadlet -dryrun, or the built-in validator if it is not installed")
+	rootCmd.PersistentFlags().StringVar(&quadletBin, "quadlet-bin", quadlet.DefaultQuadletPath, "Path to the Quadlet generator used by --verify")
+
+	installCmd.Flags().BoolVar(&installUser, "user", false, "Install rootless units for the current user (default unless running as root)")
+	installCmd.Flags().BoolVar(&installRoot, "root", false, "Install rootful units to "+quadlet.SystemUnitDir)
+	installCmd.Flags().BoolVar(&installStart, "start", false, "Start the installed services after reloading systemd")
+	installCmd.MarkFlagsMutuallyExclusive("user", "root")
+	rootCmd.AddCommand(installCmd)
 
 	// Custom version template
 	rootCmd.SetVersionTemplate(`compose2podman version {{.Version}}
@@ -71,6 +127,82 @@ func init() {
 }
 
 func run(cmd *cobra.Command, args []string) error {
+	compose, err := loadCompose()
+	if err != nil {
+		return err
+	}
+
+	switch outputType {
+	case "kube", "kubernetes":
+		return generateKube(compose, outputPath, podName)
+	case "quadlet", "kube-quadlet":
+		return generateQuadlet(compose, outputPath)
+	default:
+		return fmt.Errorf("unknown output type: %s (use 'kube', 'quadlet' or 'kube-quadlet')", outputType)
+	}
+}
+
+func runInstall(cmd *cobra.Command, args []string) error {
+	compose, err := loadCompose()
+	if err != nil {
+		return err
+	}
+
+	scope := defaultScope()
+	if installRoot {
+		scope = quadlet.ScopeSystem
+	} else if installUser {
+		scope = quadlet.ScopeUser
+	}
+
+	dir := outputPath
+	if dir == "" {
+		dir, err = quadlet.UnitDir(scope, os.Getenv)
+		if err != nil {
+			return err
+		}
+	}
+
+	plan, err := writeQuadlet(compose, dir, scope)
+	if err != nil || plan == nil {
+		return err
+	}
+	fmt.Printf("✓ Installed Quadlet files in: %s\n", dir)
+	printPlan(plan)
+
+	installer := quadlet.NewInstaller(scope)
+	if err := installer.Reload(); err != nil {
+		return err
+	}
+	fmt.Println("✓ Reloaded systemd")
+
+	if installStart {
+		units := quadlet.StartableUnits(plan)
+		if err := installer.Start(units); err != nil {
+			return err
+		}
+		for _, unit := range units {
+			fmt.Printf("✓ Started %s\n", unit)
+		}
+	}
+
+	return nil
+}
+
+// loadCompose shows the proof-of-concept warning and parses the input file
+func loadCompose() (*types.ComposeFile, error) {
+	switch types.EnvFileMode(envFile) {
+	case types.EnvFileInline, types.EnvFileReference:
+	default:
+		return nil, fmt.Errorf("unknown env file mode: %s (use 'inline' or 'reference')", envFile)
+	}
+	if err := types.ValidateAutoUpdate(autoUpdate); err != nil {
+		return nil, err
+	}
+	if err := quadlet.ValidateExitCodePropagation(exitCode); err != nil {
+		return nil, err
+	}
+
 	// Show warning unless suppressed
 	if !noWarning {
 		fmt.Fprintln(os.Stderr, "⚠️  WARNING: This is a PROOF-OF-CONCEPT tool generated by GitHub Copilot.")
@@ -87,17 +219,49 @@ func run(cmd *cobra.Command, args []string) error {
 	// Parse compose file
 	compose, err := parser.ParseComposeFile(inputFile)
 	if err != nil {
-		return fmt.Errorf("error parsing compose file: %w", err)
+		return nil, fmt.Errorf("error parsing compose file: %w", err)
 	}
 
-	switch outputType {
-	case "kube", "kubernetes":
-		return generateKube(compose, outputPath, podName)
-	case "quadlet":
-		return generateQuadlet(compose, outputPath)
-	default:
-		return fmt.Errorf("unknown output type: %s (use 'kube' or 'quadlet')", outputType)
+	compose.Name, err = parser.ResolveProjectName(compose, project, inputFile)
+	if err != nil {
+		return nil, err
+	}
+	return compose, nil
+}
+
+// defaultScope returns the scope of units written without an explicit
+// --user or --root: rootful when running as root
+func defaultScope() quadlet.Scope {
+	if os.Geteuid() == 0 {
+		return quadlet.ScopeSystem
 	}
+	return quadlet.ScopeUser
+}
+
+// optionalBool is a boolean flag that stays unset unless given, so the
+// Podman default applies when it is omitted
+type optionalBool struct {
+	value *bool
+}
+
+func (b *optionalBool) Set(s string) error {
+	v, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+	b.value = &v
+	return nil
+}
+
+func (b *optionalBool) String() string {
+	if b.value == nil {
+		return ""
+	}
+	return strconv.FormatBool(*b.value)
+}
+
+func (b *optionalBool) Type() string {
+	return "bool"
 }
 
 // findComposeFile looks for standard docker-compose file names in the current directory
@@ -122,11 +286,16 @@ func findComposeFile() string {
 }
 
 func generateKube(compose *types.ComposeFile, outputPath, podName string) error {
-	gen := kube.NewGenerator(compose, podName)
+	gen := kube.NewGeneratorWithOptions(compose, podName, kube.Options{
+		EnvFileMode: types.EnvFileMode(envFile),
+		LookupEnv:   os.LookupEnv,
+		AutoUpdate:  autoUpdate,
+	})
 	yaml, err := gen.Generate()
 	if err != nil {
 		return err
 	}
+	printWarnings(gen.Warnings())
 
 	if outputPath == "" {
 		outputPath = "pod.yaml"
@@ -148,28 +317,112 @@ func generateQuadlet(compose *types.ComposeFile, outputPath string) error {
 		outputPath = "quadlet-output"
 	}
 
-	gen := quadlet.NewGenerator(compose, outputPath)
-	if err := gen.Generate(); err != nil {
+	plan, err := writeQuadlet(compose, outputPath, defaultScope())
+	if err != nil || plan == nil {
 		return err
 	}
 
 	fmt.Printf("✓ Generated Quadlet files in: %s\n", outputPath)
-	fmt.Printf("  Copy files to: ~/.config/containers/systemd/ or /etc/containers/systemd/\n")
-	fmt.Printf("  Then run: systemctl --user daemon-reload\n")
+	fmt.Printf("  Install with: compose2podman install (or copy files to ~/.config/containers/systemd/)\n")
+	printPlan(plan)
+
+	return nil
+}
+
+// writeQuadlet plans the Quadlet files for dir, honoring --diff, --dry-run
+// and --prune. --verify checks them as units of scope. It returns a nil plan
+// when nothing was written.
+func writeQuadlet(compose *types.ComposeFile, dir string, scope quadlet.Scope) (*quadlet.Plan, error) {
+	gen := quadlet.NewGeneratorWithOptions(compose, dir, quadlet.Options{
+		EnvFileMode:    types.EnvFileMode(envFile),
+		LookupEnv:      os.LookupEnv,
+		EnvPassthrough: envPass,
+		AutoUpdate:     autoUpdate,
+		ImageUnits:     imageUnits,
+		AuthFile:       authFile,
+		TLSVerify:      tlsVerify.value,
 
-	// List generated files
-	entries, err := os.ReadDir(outputPath)
+		Kube:                outputType == "kube-quadlet",
+		PodName:             podName,
+		ExitCodePropagation: exitCode,
+	})
+	plan, err := gen.Plan()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error reading output directory: %v\n", err)
-		os.Exit(1)
+		return nil, err
 	}
-	if len(entries) > 0 {
-		fmt.Printf("  Generated files:\n")
-		for _, entry := range entries {
-			ext := filepath.Ext(entry.Name())
-			fmt.Printf("    - %s (%s)\n", entry.Name(), ext)
+	printWarnings(gen.Warnings())
+
+	if showDiff {
+		fmt.Print(plan.Diff(prune))
+	}
+
+	if dryRun {
+		fmt.Printf("Dry run: no files written to %s\n", dir)
+		printPlan(plan)
+		if verify {
+			// Nothing is on disk for quadlet to read, so only the built-in validator can run
+			return nil, reportIssues(quadlet.Validate(plan.Files()))
 		}
+		return nil, nil
 	}
 
-	return nil
+	if err := gen.Apply(plan, prune); err != nil {
+		return nil, err
+	}
+
+	if verify {
+		if err := verifyQuadlet(plan, dir, scope); err != nil {
+			return nil, err
+		}
+	}
+	return plan, nil
+}
+
+// verifyQuadlet runs quadlet -dryrun against dir for units of scope,
+// falling back to the built-in key validator when the Quadlet binary is not
+// installed
+func verifyQuadlet(plan *quadlet.Plan, dir string, scope quadlet.Scope) error {
+	issues, err := quadlet.VerifyDir(quadlet.ExecRunner{}, quadletBin, dir, scope)
+	if errors.Is(err, quadlet.ErrQuadletNotFound) {
+		fmt.Fprintf(os.Stderr, "Warning: %v; using built-in validator\n", err)
+		issues = quadlet.Validate(plan.Files())
+	} else if err != nil {
+		return err
+	}
+	return reportIssues(issues)
+}
+
+// reportIssues prints verification issues and fails if there are any
+func reportIssues(issues []quadlet.Issue) error {
+	if len(issues) == 0 {
+		fmt.Println("✓ Quadlet verification passed")
+		return nil
+	}
+	for _, issue := range issues {
+		fmt.Fprintf(os.Stderr, "  ✗ %s\n", issue)
+	}
+	return fmt.Errorf("quadlet verification found %d issue(s)", len(issues))
+}
+
+// printWarnings reports conversion warnings on stderr
+func printWarnings(warnings []string) {
+	for _, warning := range warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+	}
+}
+
+// printPlan lists the files of a Quadlet plan together with their action.
+// Stale files are reported as removed only when --prune is set.
+func printPlan(plan *quadlet.Plan) {
+	if len(plan.Changes) == 0 {
+		return
+	}
+	fmt.Printf("  Files:\n")
+	for _, change := range plan.Changes {
+		action := string(change.Action)
+		if change.Action == quadlet.ActionRemove && !prune {
+			action = "stale, use --prune to remove"
+		}
+		fmt.Printf("    - %s (%s)\n", change.Name, action)
+	}
 }
@@ -2,81 +2,370 @@
 // helper methods for handling flexible field types (maps vs arrays).
 package types
 
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Labels identifying the Compose project a generated resource belongs to
+const (
+	LabelComposeProject = "com.docker.compose.project"
+	LabelPodmanProject  = "io.podman.compose.project"
+)
+
 // ComposeFile represents a Docker Compose file structure
 type ComposeFile struct {
-	Version  string             `yaml:"version"`
-	Services map[string]Service `yaml:"services"`
-	Networks map[string]Network `yaml:"networks,omitempty"`
-	Volumes  map[string]Volume  `yaml:"volumes,omitempty"`
+	// Name is the project name. It is read from the top-level name: field
+	// and replaced by the resolved project name after parsing.
+	Name    string `yaml:"name,omitempty"`
+	Version string `yaml:"version"`
+	// WorkingDir is the directory of the compose file; relative paths in
+	// the file are resolved against it
+	WorkingDir string             `yaml:"-"`
+	Services   map[string]Service `yaml:"services"`
+	Networks   map[string]Network `yaml:"networks,omitempty"`
+	Volumes    map[string]Volume  `yaml:"volumes,omitempty"`
+	// XPodman holds the top-level x-podman extension: defaults for all
+	// services and raw keys for the network, volume, image and kube units
+	XPodman *XPodman `yaml:"x-podman,omitempty"`
 }
 
 // Service represents a service definition in Docker Compose
 type Service struct {
-	Image         string            `yaml:"image,omitempty"`
-	ContainerName string            `yaml:"container_name,omitempty"`
-	Restart       string            `yaml:"restart,omitempty"`
-	WorkingDir    string            `yaml:"working_dir,omitempty"`
-	User          string            `yaml:"user,omitempty"`
-	Hostname      string            `yaml:"hostname,omitempty"`
-	Privileged    bool              `yaml:"privileged,omitempty"`
-	Build         interface{}       `yaml:"build,omitempty"`
-	Ports         []string          `yaml:"ports,omitempty"`
-	Environment   interface{}       `yaml:"environment,omitempty"`
-	Volumes       []string          `yaml:"volumes,omitempty"`
-	Networks      interface{}       `yaml:"networks,omitempty"`
-	DependsOn     interface{}       `yaml:"depends_on,omitempty"`
-	Command       interface{}       `yaml:"command,omitempty"`
-	Entrypoint    interface{}       `yaml:"entrypoint,omitempty"`
-	Labels        map[string]string `yaml:"labels,omitempty"`
-	CapAdd        []string          `yaml:"cap_add,omitempty"`
-	CapDrop       []string          `yaml:"cap_drop,omitempty"`
+	Image             string                 `yaml:"image,omitempty"`
+	PullPolicy        string                 `yaml:"pull_policy,omitempty"`
+	Platform          string                 `yaml:"platform,omitempty"`
+	ContainerName     string                 `yaml:"container_name,omitempty"`
+	Restart           string                 `yaml:"restart,omitempty"`
+	WorkingDir        string                 `yaml:"working_dir,omitempty"`
+	User              string                 `yaml:"user,omitempty"`
+	Hostname          string                 `yaml:"hostname,omitempty"`
+	Domainname        string                 `yaml:"domainname,omitempty"`
+	DNS               interface{}            `yaml:"dns,omitempty"`
+	DNSSearch         interface{}            `yaml:"dns_search,omitempty"`
+	DNSOpt            []string               `yaml:"dns_opt,omitempty"`
+	ExtraHosts        interface{}            `yaml:"extra_hosts,omitempty"`
+	Privileged        bool                   `yaml:"privileged,omitempty"`
+	Build             interface{}            `yaml:"build,omitempty"`
+	Ports             []string               `yaml:"ports,omitempty"`
+	Environment       interface{}            `yaml:"environment,omitempty"`
+	EnvFile           interface{}            `yaml:"env_file,omitempty"`
+	Volumes           []string               `yaml:"volumes,omitempty"`
+	Networks          interface{}            `yaml:"networks,omitempty"`
+	NetworkMode       string                 `yaml:"network_mode,omitempty"`
+	DependsOn         interface{}            `yaml:"depends_on,omitempty"`
+	Command           interface{}            `yaml:"command,omitempty"`
+	Entrypoint        interface{}            `yaml:"entrypoint,omitempty"`
+	Labels            interface{}            `yaml:"labels,omitempty"`
+	LabelFile         interface{}            `yaml:"label_file,omitempty"`
+	Annotations       interface{}            `yaml:"annotations,omitempty"`
+	CapAdd            []string               `yaml:"cap_add,omitempty"`
+	CapDrop           []string               `yaml:"cap_drop,omitempty"`
+	SecurityOpt       []string               `yaml:"security_opt,omitempty"`
+	ReadOnly          bool                   `yaml:"read_only,omitempty"`
+	UsernsMode        string                 `yaml:"userns_mode,omitempty"`
+	GroupAdd          []string               `yaml:"group_add,omitempty"`
+	Devices           interface{}            `yaml:"devices,omitempty"`
+	DeviceCgroupRules []string               `yaml:"device_cgroup_rules,omitempty"`
+	Deploy            *Deploy                `yaml:"deploy,omitempty"`
+	Tmpfs             interface{}            `yaml:"tmpfs,omitempty"`
+	ShmSize           string                 `yaml:"shm_size,omitempty"`
+	Ulimits           map[string]interface{} `yaml:"ulimits,omitempty"`
+	Sysctls           interface{}            `yaml:"sysctls,omitempty"`
+	StopSignal        string                 `yaml:"stop_signal,omitempty"`
+	StopGracePeriod   string                 `yaml:"stop_grace_period,omitempty"`
+	Init              *bool                  `yaml:"init,omitempty"`
+	PostStart         []Hook                 `yaml:"post_start,omitempty"`
+	PreStop           []Hook                 `yaml:"pre_stop,omitempty"`
+	Tty               bool                   `yaml:"tty,omitempty"`
+	StdinOpen         bool                   `yaml:"stdin_open,omitempty"`
+	Pid               string                 `yaml:"pid,omitempty"`
+	Ipc               string                 `yaml:"ipc,omitempty"`
+	Cgroup            string                 `yaml:"cgroup,omitempty"`
+	CgroupParent      string                 `yaml:"cgroup_parent,omitempty"`
+	Logging           *Logging               `yaml:"logging,omitempty"`
+
+	// XPodman holds Podman specific settings from the x-podman extension
+	XPodman *XPodman `yaml:"x-podman,omitempty"`
+
+	// EnvFileVars holds the variables loaded from env_file, merged in order
+	EnvFileVars map[string]string `yaml:"-"`
+	// EnvFilePaths holds the resolved paths of the env files that exist
+	// and that Podman can read as is
+	EnvFilePaths []string `yaml:"-"`
+	// EnvFileInline holds the env_file variables Podman would read
+	// differently from Compose, because their value is quoted or
+	// interpolated, with their Compose values
+	EnvFileInline map[string]string `yaml:"-"`
+	// LabelFileVars holds the labels loaded from label_file, merged in order
+	LabelFileVars map[string]string `yaml:"-"`
+}
+
+// EnvFileMode selects how generators use the variables of env_file
+type EnvFileMode string
+
+// Env file modes
+const (
+	// EnvFileInline writes the variables into the generated output
+	EnvFileInline EnvFileMode = "inline"
+	// EnvFileReference references the files (EnvironmentFile=) or a
+	// ConfigMap (envFrom) instead of copying their values
+	EnvFileReference EnvFileMode = "reference"
+)
+
+// EnvFile is one entry of a service's env_file
+type EnvFile struct {
+	Path     string
+	Required bool
+	Format   string
 }
 
 // Network represents a network definition
 type Network struct {
-	Driver   string            `yaml:"driver,omitempty"`
-	External bool              `yaml:"external,omitempty"`
-	Labels   map[string]string `yaml:"labels,omitempty"`
+	Name       string            `yaml:"name,omitempty"`
+	Driver     string            `yaml:"driver,omitempty"`
+	DriverOpts map[string]string `yaml:"driver_opts,omitempty"`
+	External   bool              `yaml:"external,omitempty"`
+	Internal   bool              `yaml:"internal,omitempty"`
+	EnableIPv6 bool              `yaml:"enable_ipv6,omitempty"`
+	Attachable bool              `yaml:"attachable,omitempty"`
+	IPAM       IPAM              `yaml:"ipam,omitempty"`
+	Labels     map[string]string `yaml:"labels,omitempty"`
+}
+
+// IPAM represents the IP address management settings of a network
+type IPAM struct {
+	Driver  string            `yaml:"driver,omitempty"`
+	Config  []IPAMConfig      `yaml:"config,omitempty"`
+	Options map[string]string `yaml:"options,omitempty"`
+}
+
+// IPAMConfig represents one address pool of a network
+type IPAMConfig struct {
+	Subnet       string            `yaml:"subnet,omitempty"`
+	Gateway      string            `yaml:"gateway,omitempty"`
+	IPRange      string            `yaml:"ip_range,omitempty"`
+	AuxAddresses map[string]string `yaml:"aux_addresses,omitempty"`
 }
 
 // Volume represents a volume definition
 type Volume struct {
-	Driver   string            `yaml:"driver,omitempty"`
-	External bool              `yaml:"external,omitempty"`
-	Labels   map[string]string `yaml:"labels,omitempty"`
+	Name       string            `yaml:"name,omitempty"`
+	Driver     string            `yaml:"driver,omitempty"`
+	DriverOpts map[string]string `yaml:"driver_opts,omitempty"`
+	External   bool              `yaml:"external,omitempty"`
+	Labels     map[string]string `yaml:"labels,omitempty"`
+}
+
+// ResourceName returns the Compose-compatible name <project>_<name> of a
+// project-scoped resource, or name itself when no project is set
+func (c *ComposeFile) ResourceName(name string) string {
+	if c.Name == "" {
+		return name
+	}
+	return fmt.Sprintf("%s_%s", c.Name, name)
+}
+
+// NetworkName returns the Podman network name of the network with the given
+// key. An explicit name: is used as is, and external networks are never
+// scoped to the project since they are created outside of it.
+func (c *ComposeFile) NetworkName(key string) string {
+	network := c.Networks[key]
+	switch {
+	case network.Name != "":
+		return network.Name
+	case network.External:
+		return key
+	default:
+		return c.ResourceName(key)
+	}
+}
+
+// VolumeName returns the Podman volume name of the volume with the given
+// key, following the same rules as NetworkName
+func (c *ComposeFile) VolumeName(key string) string {
+	volume := c.Volumes[key]
+	switch {
+	case volume.Name != "":
+		return volume.Name
+	case volume.External:
+		return key
+	default:
+		return c.ResourceName(key)
+	}
+}
+
+// ContainerName returns the container name of a service: its container_name,
+// or <project>-<service>-1 like Compose does
+func (c *ComposeFile) ContainerName(name string, service Service) string {
+	if service.ContainerName != "" {
+		return service.ContainerName
+	}
+	if c.Name == "" {
+		return name
+	}
+	return fmt.Sprintf("%s-%s-1", c.Name, name)
+}
+
+// ProjectLabels returns the labels marking a resource as part of the project
+func (c *ComposeFile) ProjectLabels() map[string]string {
+	if c.Name == "" {
+		return nil
+	}
+	return map[string]string{
+		LabelComposeProject: c.Name,
+		LabelPodmanProject:  c.Name,
+	}
+}
+
+// ResolvePath resolves a host path relative to the compose file directory,
+// leaving absolute paths and paths of files parsed from memory unchanged
+func (c *ComposeFile) ResolvePath(path string) string {
+	if c.WorkingDir == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(c.WorkingDir, path)
 }
 
-// EnvironmentMap converts environment interface to map
+// EnvironmentMap converts environment interface to map. Numbers and booleans
+// are kept in their Compose string form; variables without a value are
+// omitted, see EnvironmentVars and ResolveEnvironment.
 func (s *Service) EnvironmentMap() map[string]string {
 	env := make(map[string]string)
+	for _, v := range s.EnvironmentVars() {
+		if v.Set {
+			env[v.Name] = v.Value
+		}
+	}
+	return env
+}
+
+// EnvVar is a single variable of a service environment. Variables declared
+// without a value (KEY: in map form or a bare KEY list entry) have Set false
+// and take their value from the environment Compose runs in.
+type EnvVar struct {
+	Name  string
+	Value string
+	Set   bool
+}
+
+// EnvironmentVars returns the variables of the environment section sorted
+// by name, including those declared without a value
+func (s *Service) EnvironmentVars() []EnvVar {
+	var vars []EnvVar
 
 	switch v := s.Environment.(type) {
-	case map[string]interface{}:
-		for key, val := range v {
-			if str, ok := val.(string); ok {
-				env[key] = str
+	case map[string]interface{}, map[interface{}]interface{}:
+		for key, val := range toStringMap(v) {
+			if val == nil {
+				vars = append(vars, EnvVar{Name: key})
+				continue
 			}
+			vars = append(vars, EnvVar{Name: key, Value: toString(val), Set: true})
 		}
-	case map[interface{}]interface{}:
-		for key, val := range v {
-			if keyStr, ok := key.(string); ok {
-				if valStr, ok := val.(string); ok {
-					env[keyStr] = valStr
-				}
+	case []interface{}:
+		for _, item := range v {
+			str := toString(item)
+			if idx := findEquals(str); idx > 0 {
+				vars = append(vars, EnvVar{Name: str[:idx], Value: str[idx+1:], Set: true})
+			} else if str != "" {
+				vars = append(vars, EnvVar{Name: str})
 			}
 		}
+	}
+
+	sort.Slice(vars, func(i, j int) bool { return vars[i].Name < vars[j].Name })
+	return vars
+}
+
+// ResolveEnvironment returns the variables of env_file (when withEnvFile is
+// set) overridden by environment, following Compose precedence. Variables
+// declared without a value are looked up with lookup; those lookup cannot
+// resolve keep their env_file value if any and are otherwise returned as
+// unresolved, sorted by name. A nil lookup resolves nothing.
+func (s *Service) ResolveEnvironment(withEnvFile bool, lookup func(string) (string, bool)) (map[string]string, []string) {
+	env := make(map[string]string)
+	if withEnvFile {
+		for key, val := range s.EnvFileVars {
+			env[key] = val
+		}
+	}
+
+	var unresolved []string
+	for _, v := range s.EnvironmentVars() {
+		if v.Set {
+			env[v.Name] = v.Value
+			continue
+		}
+		if lookup != nil {
+			if val, ok := lookup(v.Name); ok {
+				env[v.Name] = val
+				continue
+			}
+		}
+		if _, ok := env[v.Name]; !ok {
+			unresolved = append(unresolved, v.Name)
+		}
+	}
+	return env, unresolved
+}
+
+// LabelsMap returns the labels of a service in map or list form, on top of
+// the labels loaded from label_file. A label without a value is empty.
+func (s *Service) LabelsMap() map[string]string {
+	labels := make(map[string]string, len(s.LabelFileVars))
+	for key, val := range s.LabelFileVars {
+		labels[key] = val
+	}
+	for key, val := range toKeyValueMap(s.Labels) {
+		labels[key] = val
+	}
+	return labels
+}
+
+// LabelFiles returns the label_file paths of a service
+func (s *Service) LabelFiles() []string {
+	return toStringList(s.LabelFile)
+}
+
+// AnnotationsMap returns the annotations of a service in map or list form
+func (s *Service) AnnotationsMap() map[string]string {
+	return toKeyValueMap(s.Annotations)
+}
+
+// EnvFiles returns the env_file entries of a service. Both the short
+// syntax (a string or list of paths) and the long syntax with path,
+// required and format are supported; files are required by default.
+func (s *Service) EnvFiles() []EnvFile {
+	var files []EnvFile
+
+	switch v := s.EnvFile.(type) {
+	case string:
+		files = append(files, EnvFile{Path: v, Required: true})
 	case []interface{}:
 		for _, item := range v {
 			if str, ok := item.(string); ok {
-				// Handle KEY=VALUE format
-				if idx := findEquals(str); idx > 0 {
-					env[str[:idx]] = str[idx+1:]
-				}
+				files = append(files, EnvFile{Path: str, Required: true})
+				continue
+			}
+			entry := toStringMap(item)
+			if entry == nil {
+				continue
+			}
+			file := EnvFile{Path: toString(entry["path"]), Required: true, Format: toString(entry["format"])}
+			if required, ok := entry["required"].(bool); ok {
+				file.Required = required
+			}
+			if file.Path != "" {
+				files = append(files, file)
 			}
 		}
 	}
 
-	return env
+	return files
 }
 
 // NetworksList returns networks as a list of strings
@@ -102,9 +391,69 @@ func (s *Service) NetworksList() []string {
 		}
 	}
 
+	// Map iteration order is random; keep the output stable
+	if _, isList := s.Networks.([]interface{}); !isList {
+		sort.Strings(networks)
+	}
+
 	return networks
 }
 
+// ServiceNetwork is the configuration of a network a service is attached to
+type ServiceNetwork struct {
+	Name         string
+	Aliases      []string
+	IPv4Address  string
+	IPv6Address  string
+	LinkLocalIPs []string
+	Priority     int
+	MacAddress   string
+}
+
+// NetworkAttachments returns the networks of a service with their settings.
+// The map form is ordered like Compose does, by descending priority and
+// then by name; the list form keeps its order.
+func (s *Service) NetworkAttachments() []ServiceNetwork {
+	attachments := make([]ServiceNetwork, 0, len(s.NetworksList()))
+	for _, name := range s.NetworksList() {
+		attachment := ServiceNetwork{Name: name}
+		if config := toStringMap(mapValue(s.Networks, name)); config != nil {
+			attachment.Aliases = toStringList(config["aliases"])
+			attachment.IPv4Address = toString(config["ipv4_address"])
+			attachment.IPv6Address = toString(config["ipv6_address"])
+			attachment.LinkLocalIPs = toStringList(config["link_local_ips"])
+			attachment.MacAddress = toString(config["mac_address"])
+			if priority, ok := config["priority"].(int); ok {
+				attachment.Priority = priority
+			}
+		}
+		attachments = append(attachments, attachment)
+	}
+
+	sort.SliceStable(attachments, func(i, j int) bool {
+		return attachments[i].Priority > attachments[j].Priority
+	})
+	return attachments
+}
+
+// NetworkModeService returns the service whose network namespace is shared
+// through network_mode: service:<name>
+func (s *Service) NetworkModeService() (string, bool) {
+	return strings.CutPrefix(s.NetworkMode, "service:")
+}
+
+// NamespaceServices returns the services whose namespaces are shared
+// through network_mode, pid or ipc set to service:<name>, without duplicates
+func (s *Service) NamespaceServices() []string {
+	var owners []string
+	for _, mode := range []string{s.NetworkMode, s.Pid, s.Ipc} {
+		if owner, ok := strings.CutPrefix(mode, "service:"); ok && !slices.Contains(owners, owner) {
+			owners = append(owners, owner)
+		}
+	}
+	return owners
+}
+
 // DependsOnList returns dependencies as a list of strings
 func (s *Service) DependsOnList() []string {
 	var deps []string
@@ -128,6 +477,11 @@ func (s *Service) DependsOnList() []string {
 		}
 	}
 
+	// Map iteration order is random; keep the output stable
+	if _, isList := s.DependsOn.([]interface{}); !isList {
+		sort.Strings(deps)
+	}
+
 	return deps
 }
 
@@ -165,6 +519,83 @@ func (s *Service) EntrypointList() []string {
 	return nil
 }
 
+// mapValue returns the value stored under key in a decoded YAML mapping
+func mapValue(v interface{}, key string) interface{} {
+	switch m := v.(type) {
+	case map[string]interface{}:
+		return m[key]
+	case map[interface{}]interface{}:
+		return m[key]
+	}
+	return nil
+}
+
+// toStringMap converts a decoded YAML mapping to a map with string keys
+func toStringMap(v interface{}) map[string]interface{} {
+	switch m := v.(type) {
+	case map[string]interface{}:
+		return m
+	case map[interface{}]interface{}:
+		result := make(map[string]interface{}, len(m))
+		for key, val := range m {
+			if keyStr, ok := key.(string); ok {
+				result[keyStr] = val
+			}
+		}
+		return result
+	}
+	return nil
+}
+
+// toKeyValueMap converts a decoded YAML mapping or a list of KEY=VALUE
+// strings to a map. Null values and entries without "=" are empty.
+func toKeyValueMap(v interface{}) map[string]string {
+	result := make(map[string]string)
+	if list, ok := v.([]interface{}); ok {
+		for _, item := range list {
+			key, val, _ := strings.Cut(toString(item), "=")
+			if key != "" {
+				result[key] = val
+			}
+		}
+		return result
+	}
+	for key, val := range toStringMap(v) {
+		result[key] = toString(val)
+	}
+	return result
+}
+
+// toString returns a decoded YAML scalar as string, or "" for anything else
+func toString(v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case int, int64, float64, bool:
+		return fmt.Sprint(val)
+	}
+	return ""
+}
+
+// toStringList converts a decoded YAML string or sequence to a string slice
+func toStringList(v interface{}) []string {
+	switch val := v.(type) {
+	case string:
+		return []string{val}
+	case []interface{}:
+		list := make([]string, 0, len(val))
+		for _, item := range val {
+			if str := toString(item); str != "" {
+				list = append(list, str)
+			}
+		}
+		return list
+	case []string:
+		return val
+	}
+	return nil
+}
+
 func findEquals(s string) int {
 	for i, c := range s {
 		if c == '=' {
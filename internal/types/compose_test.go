@@ -30,6 +30,34 @@ func TestServiceEnvironmentMap(t *testing.T) {
 				"KEY2": "value2",
 			},
 		},
+		{
+			name: "non-string values",
+			env: map[string]interface{}{
+				"PORT":  8080,
+				"DEBUG": true,
+				"RATIO": 0.5,
+				"EMPTY": "",
+				"HOST":  nil,
+			},
+			expected: map[string]string{
+				"PORT":  "8080",
+				"DEBUG": "true",
+				"RATIO": "0.5",
+				"EMPTY": "",
+			},
+		},
+		{
+			name: "array without value",
+			env: []interface{}{
+				"KEY1=value1",
+				"KEY2=",
+				"HOST",
+			},
+			expected: map[string]string{
+				"KEY1": "value1",
+				"KEY2": "",
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -50,6 +78,38 @@ func TestServiceEnvironmentMap(t *testing.T) {
 	}
 }
 
+func TestServiceResolveEnvironment(t *testing.T) {
+	svc := Service{
+		Environment: []interface{}{"SET=1", "HOME", "FROM_FILE", "MISSING"},
+		EnvFileVars: map[string]string{"FROM_FILE": "file", "SET": "file"},
+	}
+	lookup := func(key string) (string, bool) {
+		if key == "HOME" {
+			return "/root", true
+		}
+		return "", false
+	}
+
+	env, unresolved := svc.ResolveEnvironment(true, lookup)
+	expected := map[string]string{"SET": "1", "HOME": "/root", "FROM_FILE": "file"}
+	if len(env) != len(expected) {
+		t.Errorf("Expected %v, got %v", expected, env)
+	}
+	for key, val := range expected {
+		if env[key] != val {
+			t.Errorf("For key %s: expected %q, got %q", key, val, env[key])
+		}
+	}
+	if len(unresolved) != 1 || unresolved[0] != "MISSING" {
+		t.Errorf("Expected [MISSING] unresolved, got %v", unresolved)
+	}
+
+	_, unresolved = svc.ResolveEnvironment(false, nil)
+	if len(unresolved) != 3 {
+		t.Errorf("Expected 3 unresolved without env_file and lookup, got %v", unresolved)
+	}
+}
+
 func TestServiceCommandList(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -130,3 +190,41 @@ func TestServiceNetworksList(t *testing.T) {
 		})
 	}
 }
+
+func TestServiceNetworkAttachments(t *testing.T) {
+	svc := Service{Networks: map[string]interface{}{
+		"backend": nil,
+		"frontend": map[string]interface{}{
+			"aliases":      []interface{}{"api", "api.internal"},
+			"ipv4_address": "10.0.0.5",
+			"ipv6_address": "fd00::5",
+			"mac_address":  "02:42:ac:11:00:02",
+			"priority":     100,
+		},
+		"admin": map[string]interface{}{"priority": 10},
+	}}
+
+	attachments := svc.NetworkAttachments()
+
+	names := make([]string, 0, len(attachments))
+	for _, a := range attachments {
+		names = append(names, a.Name)
+	}
+	expectedOrder := []string{"frontend", "admin", "backend"}
+	for i, name := range expectedOrder {
+		if i >= len(names) || names[i] != name {
+			t.Fatalf("Expected order %v, got %v", expectedOrder, names)
+		}
+	}
+
+	frontend := attachments[0]
+	if len(frontend.Aliases) != 2 || frontend.Aliases[0] != "api" {
+		t.Errorf("Unexpected aliases: %v", frontend.Aliases)
+	}
+	if frontend.IPv4Address != "10.0.0.5" || frontend.IPv6Address != "fd00::5" {
+		t.Errorf("Unexpected addresses: %s %s", frontend.IPv4Address, frontend.IPv6Address)
+	}
+	if frontend.MacAddress != "02:42:ac:11:00:02" {
+		t.Errorf("Unexpected mac address: %s", frontend.MacAddress)
+	}
+}
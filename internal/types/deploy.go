@@ -0,0 +1,188 @@
+package types
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Deploy holds the parts of the Compose deploy section that apply to a
+// single host
+type Deploy struct {
+	Resources     Resources      `yaml:"resources,omitempty"`
+	RestartPolicy *RestartPolicy `yaml:"restart_policy,omitempty"`
+}
+
+// RestartPolicy configures if and how containers are restarted when they
+// exit
+type RestartPolicy struct {
+	// Condition is none, on-failure or any (the default)
+	Condition   string `yaml:"condition,omitempty"`
+	Delay       string `yaml:"delay,omitempty"`
+	MaxAttempts int    `yaml:"max_attempts,omitempty"`
+	Window      string `yaml:"window,omitempty"`
+}
+
+// Resources holds the resource constraints of a service
+type Resources struct {
+	Reservations ResourceSpec `yaml:"reservations,omitempty"`
+}
+
+// ResourceSpec holds reserved resources
+type ResourceSpec struct {
+	Devices []DeviceRequest `yaml:"devices,omitempty"`
+}
+
+// DeviceRequest requests devices from a driver, such as GPUs
+type DeviceRequest struct {
+	Driver       string            `yaml:"driver,omitempty"`
+	Count        interface{}       `yaml:"count,omitempty"`
+	DeviceIDs    []string          `yaml:"device_ids,omitempty"`
+	Capabilities []string          `yaml:"capabilities,omitempty"`
+	Options      map[string]string `yaml:"options,omitempty"`
+}
+
+// nvidiaCDIKind is the CDI kind of NVIDIA GPUs, as generated by
+// nvidia-ctk cdi generate
+const nvidiaCDIKind = "nvidia.com/gpu"
+
+// Device is a host device made available to a container
+type Device struct {
+	Source      string
+	Target      string
+	Permissions string
+}
+
+// IsCDI reports whether the device is a fully qualified CDI device name,
+// vendor/class=name, rather than a host path
+func (d Device) IsCDI() bool {
+	return !strings.HasPrefix(d.Source, "/") && strings.Contains(d.Source, "/") && strings.Contains(d.Source, "=")
+}
+
+// String returns the device in the form accepted by podman --device
+func (d Device) String() string {
+	if d.IsCDI() {
+		return d.Source
+	}
+	s := d.Source
+	if d.Target != "" && (d.Target != d.Source || d.Permissions != "") {
+		s += ":" + d.Target
+	}
+	if d.Permissions != "" {
+		s += ":" + d.Permissions
+	}
+	return s
+}
+
+// DevicesList returns the devices of a service in short (source[:target
+// [:permissions]] or a CDI name) or long form. The target defaults to
+// the source.
+func (s *Service) DevicesList() []Device {
+	list, ok := s.Devices.([]interface{})
+	if !ok {
+		return nil
+	}
+
+	var devices []Device
+	for _, item := range list {
+		var device Device
+		if str, ok := item.(string); ok {
+			device = parseDevice(str)
+		} else if entry := toStringMap(item); entry != nil {
+			device = Device{
+				Source:      toString(entry["source"]),
+				Target:      toString(entry["target"]),
+				Permissions: toString(entry["permissions"]),
+			}
+		}
+		if device.Source == "" {
+			continue
+		}
+		if device.Target == "" && !device.IsCDI() {
+			device.Target = device.Source
+		}
+		devices = append(devices, device)
+	}
+	return devices
+}
+
+func parseDevice(s string) Device {
+	device := Device{Source: s}
+	if device.IsCDI() {
+		return device
+	}
+	parts := strings.SplitN(s, ":", 3)
+	device.Source = parts[0]
+	if len(parts) > 1 {
+		device.Target = parts[1]
+	}
+	if len(parts) > 2 {
+		device.Permissions = parts[2]
+	}
+	return device
+}
+
+// CDIDevices returns the CDI device names requested with
+// deploy.resources.reservations.devices. Requests for the cdi driver name
+// devices directly; NVIDIA GPU requests are translated to the nvidia.com/gpu
+// kind. Other requests are returned as errors so callers can warn about them.
+func (s *Service) CDIDevices() ([]string, []error) {
+	if s.Deploy == nil {
+		return nil, nil
+	}
+
+	var names []string
+	var errs []error
+	for _, request := range s.Deploy.Resources.Reservations.Devices {
+		switch {
+		case request.Driver == "cdi":
+			names = append(names, request.DeviceIDs...)
+		case request.Driver == "nvidia" || (request.Driver == "" && slices.Contains(request.Capabilities, "gpu")):
+			ids, err := gpuDeviceIDs(request)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			for _, id := range ids {
+				names = append(names, nvidiaCDIKind+"="+id)
+			}
+		default:
+			errs = append(errs, fmt.Errorf("device request for driver %q with capabilities %v has no CDI equivalent", request.Driver, request.Capabilities))
+		}
+	}
+	return names, errs
+}
+
+// gpuDeviceIDs returns the GPU indexes or UUIDs of a request: device_ids,
+// or the first count GPUs, or all of them
+func gpuDeviceIDs(request DeviceRequest) ([]string, error) {
+	if len(request.DeviceIDs) > 0 {
+		return request.DeviceIDs, nil
+	}
+
+	switch count := request.Count.(type) {
+	case nil:
+		return []string{"all"}, nil
+	case string:
+		if count == "all" {
+			return []string{"all"}, nil
+		}
+		n, err := strconv.Atoi(count)
+		if err != nil {
+			return nil, fmt.Errorf("invalid device count %q", count)
+		}
+		return indexes(n), nil
+	case int:
+		return indexes(count), nil
+	}
+	return nil, fmt.Errorf("invalid device count %v", request.Count)
+}
+
+func indexes(n int) []string {
+	ids := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		ids = append(ids, strconv.Itoa(i))
+	}
+	return ids
+}
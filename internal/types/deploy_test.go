@@ -0,0 +1,53 @@
+package types
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestServiceDevicesList(t *testing.T) {
+	svc := Service{Devices: []interface{}{
+		"/dev/ttyUSB0",
+		"/dev/sda:/dev/xvda:r",
+		"vendor.com/device=gpu0",
+		map[string]interface{}{"source": "/dev/dri", "permissions": "rw"},
+	}}
+
+	expected := []Device{
+		{Source: "/dev/ttyUSB0", Target: "/dev/ttyUSB0"},
+		{Source: "/dev/sda", Target: "/dev/xvda", Permissions: "r"},
+		{Source: "vendor.com/device=gpu0"},
+		{Source: "/dev/dri", Target: "/dev/dri", Permissions: "rw"},
+	}
+	devices := svc.DevicesList()
+	if !reflect.DeepEqual(devices, expected) {
+		t.Fatalf("DevicesList() = %+v, want %+v", devices, expected)
+	}
+
+	strs := make([]string, 0, len(devices))
+	for _, device := range devices {
+		strs = append(strs, device.String())
+	}
+	if !reflect.DeepEqual(strs, []string{"/dev/ttyUSB0", "/dev/sda:/dev/xvda:r", "vendor.com/device=gpu0", "/dev/dri:/dev/dri:rw"}) {
+		t.Errorf("Unexpected podman device strings %v", strs)
+	}
+}
+
+func TestServiceCDIDevices(t *testing.T) {
+	svc := Service{Deploy: &Deploy{Resources: Resources{Reservations: ResourceSpec{Devices: []DeviceRequest{
+		{Driver: "cdi", DeviceIDs: []string{"vendor.com/fpga=0"}},
+		{Driver: "nvidia", Count: 2, Capabilities: []string{"gpu"}},
+		{Capabilities: []string{"gpu"}, Count: "all"},
+		{Driver: "nvidia", DeviceIDs: []string{"GPU-1234"}},
+		{Driver: "other", Capabilities: []string{"tpu"}},
+	}}}}}
+
+	names, errs := svc.CDIDevices()
+	expected := []string{"vendor.com/fpga=0", "nvidia.com/gpu=0", "nvidia.com/gpu=1", "nvidia.com/gpu=all", "nvidia.com/gpu=GPU-1234"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("CDIDevices() = %v, want %v", names, expected)
+	}
+	if len(errs) != 1 {
+		t.Errorf("Expected one error for the unsupported driver, got %v", errs)
+	}
+}
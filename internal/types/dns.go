@@ -0,0 +1,63 @@
+package types
+
+import (
+	"sort"
+	"strings"
+)
+
+// HostGateway is the special extra_hosts address of the host as seen from
+// the container
+const HostGateway = "host-gateway"
+
+// ExtraHost is an additional /etc/hosts entry of a service
+type ExtraHost struct {
+	Host string
+	IP   string
+}
+
+// DNSList returns the dns servers of a service, given as a string or list
+func (s *Service) DNSList() []string {
+	return toStringList(s.DNS)
+}
+
+// DNSSearchList returns the dns_search domains of a service
+func (s *Service) DNSSearchList() []string {
+	return toStringList(s.DNSSearch)
+}
+
+// ExtraHostsList returns the extra_hosts of a service. The list form
+// accepts host:ip and host=ip entries, with IPv6 addresses optionally in
+// brackets; the map form maps a host to one IP or a list of IPs and is
+// returned sorted by host.
+func (s *Service) ExtraHostsList() []ExtraHost {
+	var hosts []ExtraHost
+
+	if list, ok := s.ExtraHosts.([]interface{}); ok {
+		for _, item := range list {
+			entry := toString(item)
+			idx := strings.IndexAny(entry, ":=")
+			if idx <= 0 {
+				continue
+			}
+			hosts = append(hosts, ExtraHost{Host: entry[:idx], IP: trimBrackets(entry[idx+1:])})
+		}
+		return hosts
+	}
+
+	entries := toStringMap(s.ExtraHosts)
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, ip := range toStringList(entries[name]) {
+			hosts = append(hosts, ExtraHost{Host: name, IP: trimBrackets(ip)})
+		}
+	}
+	return hosts
+}
+
+func trimBrackets(ip string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(ip, "["), "]")
+}
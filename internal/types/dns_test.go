@@ -0,0 +1,46 @@
+package types
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestServiceExtraHostsList(t *testing.T) {
+	tests := []struct {
+		name     string
+		hosts    interface{}
+		expected []ExtraHost
+	}{
+		{
+			name:  "list",
+			hosts: []interface{}{"db:10.0.0.5", "gw=host-gateway", "v6:[::1]", "v6old:::2"},
+			expected: []ExtraHost{
+				{Host: "db", IP: "10.0.0.5"},
+				{Host: "gw", IP: "host-gateway"},
+				{Host: "v6", IP: "::1"},
+				{Host: "v6old", IP: "::2"},
+			},
+		},
+		{
+			name: "map",
+			hosts: map[string]interface{}{
+				"web": []interface{}{"10.0.0.2", "10.0.0.3"},
+				"db":  "10.0.0.5",
+			},
+			expected: []ExtraHost{
+				{Host: "db", IP: "10.0.0.5"},
+				{Host: "web", IP: "10.0.0.2"},
+				{Host: "web", IP: "10.0.0.3"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := Service{ExtraHosts: tt.hosts}
+			if result := svc.ExtraHostsList(); !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("ExtraHostsList() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package types
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Hook is a post_start or pre_stop command run inside the container
+type Hook struct {
+	Command     interface{} `yaml:"command,omitempty"`
+	User        string      `yaml:"user,omitempty"`
+	Privileged  bool        `yaml:"privileged,omitempty"`
+	WorkingDir  string      `yaml:"working_dir,omitempty"`
+	Environment interface{} `yaml:"environment,omitempty"`
+}
+
+// CommandList returns the command of a hook. As in Compose, a command given
+// as a string is split into words with shell quoting rules and run without
+// a shell.
+func (h *Hook) CommandList() ([]string, error) {
+	if str, ok := h.Command.(string); ok {
+		words, err := splitShellWords(str)
+		if err != nil {
+			return nil, fmt.Errorf("command %q: %w", str, err)
+		}
+		return words, nil
+	}
+	return toStringList(h.Command), nil
+}
+
+// splitShellWords splits s into words like a POSIX shell without expanding
+// anything: words are separated by blanks, single quotes keep their content
+// as is, double quotes keep it but for backslash escapes and a backslash
+// outside quotes escapes the next character.
+func splitShellWords(s string) ([]string, error) {
+	var (
+		words []string
+		word  strings.Builder
+		// inWord is set once a word started, so "" yields an empty word
+		inWord bool
+		quote  rune
+		escape bool
+	)
+	for _, r := range s {
+		switch {
+		case escape:
+			escape = false
+			// Within double quotes only these characters can be escaped
+			if quote == '"' && !strings.ContainsRune("\"$`\\\n", r) {
+				word.WriteRune('\\')
+			}
+			// An escaped newline continues the line
+			if r != '\n' {
+				word.WriteRune(r)
+				inWord = true
+			}
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\\' && quote != '\'':
+			escape = true
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	switch {
+	case quote != 0:
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	case escape:
+		return nil, fmt.Errorf("trailing backslash")
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// EnvironmentList returns the environment of a hook as sorted KEY=VALUE
+// entries
+func (h *Hook) EnvironmentList() []string {
+	env := toKeyValueMap(h.Environment)
+	list := make([]string, 0, len(env))
+	for key, val := range env {
+		list = append(list, key+"="+val)
+	}
+	sort.Strings(list)
+	return list
+}
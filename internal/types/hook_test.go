@@ -0,0 +1,59 @@
+package types
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHookCommandList(t *testing.T) {
+	tests := []struct {
+		name     string
+		command  interface{}
+		expected []string
+		wantErr  bool
+	}{
+		{
+			name:     "list",
+			command:  []interface{}{"/app/warmup", "a && b"},
+			expected: []string{"/app/warmup", "a && b"},
+		},
+		{
+			name:     "words",
+			command:  "nginx  -s\tquit && echo done",
+			expected: []string{"nginx", "-s", "quit", "&&", "echo", "done"},
+		},
+		{
+			name:     "quotes",
+			command:  `echo 'a "b"' "c \"d\" \e $HOME" '' x\ y`,
+			expected: []string{"echo", `a "b"`, `c "d" \e $HOME`, "", "x y"},
+		},
+		{
+			name:     "line continuation",
+			command:  "echo a \\\n b",
+			expected: []string{"echo", "a", "b"},
+		},
+		{
+			name:    "unterminated quote",
+			command: `echo "a`,
+			wantErr: true,
+		},
+		{
+			name:    "trailing backslash",
+			command: `echo a\`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hook := Hook{Command: tt.command}
+			result, err := hook.CommandList()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CommandList() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("CommandList() = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Pull policies of a service, as in the pull_policy attribute
+const (
+	PullAlways  = "always"
+	PullNever   = "never"
+	PullMissing = "missing"
+	PullBuild   = "build"
+)
+
+// Auto-update policies of podman auto-update
+const (
+	AutoUpdateRegistry = "registry"
+	AutoUpdateLocal    = "local"
+	// AutoUpdateDisabled opts a service out of a default policy
+	AutoUpdateDisabled = "disabled"
+)
+
+// PullPolicy is a parsed pull_policy
+type PullPolicy struct {
+	// Policy is one of the Pull* constants, or "" when unset
+	Policy string
+	// Refresh is set for daily, weekly and every_<duration>, which pull
+	// again when the local image is older than the interval
+	Refresh bool
+}
+
+// ParsePullPolicy parses pull_policy. if_not_present is accepted as an
+// alias of missing.
+func (s *Service) ParsePullPolicy() (PullPolicy, error) {
+	switch policy := s.PullPolicy; policy {
+	case "":
+		return PullPolicy{}, nil
+	case PullAlways, PullNever, PullMissing, PullBuild:
+		return PullPolicy{Policy: policy}, nil
+	case "if_not_present":
+		return PullPolicy{Policy: PullMissing}, nil
+	case "daily", "weekly":
+		return PullPolicy{Policy: PullMissing, Refresh: true}, nil
+	default:
+		interval, ok := strings.CutPrefix(policy, "every_")
+		if !ok {
+			return PullPolicy{}, fmt.Errorf("invalid pull_policy %q", policy)
+		}
+		if _, err := ParseDuration(interval); err != nil || interval == "" {
+			return PullPolicy{}, fmt.Errorf("invalid pull_policy %q: bad interval", policy)
+		}
+		return PullPolicy{Policy: PullMissing, Refresh: true}, nil
+	}
+}
+
+// ValidateAutoUpdate checks an auto-update policy, allowing "" for unset
+func ValidateAutoUpdate(policy string) error {
+	switch policy {
+	case "", AutoUpdateRegistry, AutoUpdateLocal, AutoUpdateDisabled:
+		return nil
+	}
+	return fmt.Errorf("invalid auto-update policy %q (use registry, local or disabled)", policy)
+}
+
+// AutoUpdatePolicy returns the auto-update policy of a service: its
+// x-podman.auto-update, or defaultPolicy. "" means no auto-update.
+func (s *Service) AutoUpdatePolicy(defaultPolicy string) (string, error) {
+	policy := defaultPolicy
+	if s.XPodman != nil && s.XPodman.AutoUpdate != "" {
+		policy = s.XPodman.AutoUpdate
+	}
+	if err := ValidateAutoUpdate(policy); err != nil {
+		return "", err
+	}
+	if policy == AutoUpdateDisabled {
+		return "", nil
+	}
+	return policy, nil
+}
+
+// IsFullyQualifiedImage reports whether an image reference names its
+// registry, which podman auto-update with the registry policy requires
+func IsFullyQualifiedImage(image string) bool {
+	domain, _, found := strings.Cut(image, "/")
+	return found && (strings.ContainsAny(domain, ".:") || domain == "localhost")
+}
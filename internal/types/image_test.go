@@ -0,0 +1,73 @@
+package types
+
+import "testing"
+
+func TestServiceParsePullPolicy(t *testing.T) {
+	tests := []struct {
+		policy   string
+		expected PullPolicy
+		wantErr  bool
+	}{
+		{"", PullPolicy{}, false},
+		{"always", PullPolicy{Policy: PullAlways}, false},
+		{"if_not_present", PullPolicy{Policy: PullMissing}, false},
+		{"daily", PullPolicy{Policy: PullMissing, Refresh: true}, false},
+		{"every_12h", PullPolicy{Policy: PullMissing, Refresh: true}, false},
+		{"every_sometimes", PullPolicy{}, true},
+		{"eventually", PullPolicy{}, true},
+	}
+
+	for _, tt := range tests {
+		svc := Service{PullPolicy: tt.policy}
+		result, err := svc.ParsePullPolicy()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParsePullPolicy(%q) error = %v, wantErr %v", tt.policy, err, tt.wantErr)
+		}
+		if result != tt.expected {
+			t.Errorf("ParsePullPolicy(%q) = %+v, want %+v", tt.policy, result, tt.expected)
+		}
+	}
+}
+
+func TestServiceAutoUpdatePolicy(t *testing.T) {
+	tests := []struct {
+		name     string
+		xpodman  *XPodman
+		fallback string
+		expected string
+		wantErr  bool
+	}{
+		{"unset", nil, "", "", false},
+		{"default", nil, "registry", "registry", false},
+		{"service override", &XPodman{AutoUpdate: "local"}, "registry", "local", false},
+		{"opt out", &XPodman{AutoUpdate: "disabled"}, "registry", "", false},
+		{"invalid", &XPodman{AutoUpdate: "nightly"}, "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := Service{XPodman: tt.xpodman}
+			result, err := svc.AutoUpdatePolicy(tt.fallback)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("AutoUpdatePolicy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if result != tt.expected {
+				t.Errorf("AutoUpdatePolicy() = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestIsFullyQualifiedImage(t *testing.T) {
+	for image, expected := range map[string]bool{
+		"docker.io/library/nginx:latest": true,
+		"localhost/app":                  true,
+		"registry:5000/app":              true,
+		"nginx":                          false,
+		"library/nginx":                  false,
+	} {
+		if result := IsFullyQualifiedImage(image); result != expected {
+			t.Errorf("IsFullyQualifiedImage(%q) = %v, want %v", image, result, expected)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package types
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+)
+
+// Logging configures the log driver of a service
+type Logging struct {
+	Driver  string            `yaml:"driver,omitempty"`
+	Options map[string]string `yaml:"options,omitempty"`
+}
+
+// Podman log drivers
+const (
+	LogDriverK8sFile  = "k8s-file"
+	LogDriverJournald = "journald"
+	LogDriverNone     = "none"
+)
+
+// podmanLogDrivers maps Docker log drivers to the Podman driver with the
+// same behavior
+var podmanLogDrivers = map[string]string{
+	"json-file":       LogDriverK8sFile,
+	"local":           LogDriverK8sFile,
+	"k8s-file":        LogDriverK8sFile,
+	"journald":        LogDriverJournald,
+	"none":            LogDriverNone,
+	"passthrough":     "passthrough",
+	"passthrough-tty": "passthrough-tty",
+}
+
+// podmanLogOptions lists the options Podman supports per driver
+var podmanLogOptions = map[string][]string{
+	LogDriverK8sFile:  {"max-size", "path", "tag"},
+	LogDriverJournald: {"tag"},
+}
+
+// PodmanLogging is a logging configuration translated to Podman
+type PodmanLogging struct {
+	Driver string
+	// Options are sorted key=value pairs for --log-opt
+	Options []string
+}
+
+// PodmanLogging translates the logging section to Podman. Drivers Podman
+// lacks, such as gelf, fluentd or syslog, fall back to journald, which can
+// forward logs to those systems. Dropped settings are returned as errors so
+// callers can warn about them. A nil result means the Podman default.
+func (s *Service) PodmanLogging() (*PodmanLogging, []error) {
+	if s.Logging == nil || (s.Logging.Driver == "" && len(s.Logging.Options) == 0) {
+		return nil, nil
+	}
+
+	var errs []error
+	driver := LogDriverK8sFile
+	if s.Logging.Driver != "" {
+		var ok bool
+		if driver, ok = podmanLogDrivers[s.Logging.Driver]; !ok {
+			driver = LogDriverJournald
+			errs = append(errs, fmt.Errorf("log driver %s is not supported by Podman; using journald", s.Logging.Driver))
+		}
+	}
+
+	logging := &PodmanLogging{Driver: driver}
+	keys := make([]string, 0, len(s.Logging.Options))
+	for key := range s.Logging.Options {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if !slices.Contains(podmanLogOptions[driver], key) {
+			errs = append(errs, fmt.Errorf("log option %s is not supported by the Podman %s driver and was dropped", key, driver))
+			continue
+		}
+		logging.Options = append(logging.Options, key+"="+s.Logging.Options[key])
+	}
+	return logging, errs
+}
@@ -0,0 +1,47 @@
+package types
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestServicePodmanLogging(t *testing.T) {
+	tests := []struct {
+		name     string
+		logging  *Logging
+		expected *PodmanLogging
+		errs     int
+	}{
+		{"unset", nil, nil, 0},
+		{
+			name:     "json-file",
+			logging:  &Logging{Driver: "json-file", Options: map[string]string{"max-size": "10m", "max-file": "3"}},
+			expected: &PodmanLogging{Driver: "k8s-file", Options: []string{"max-size=10m"}},
+			errs:     1,
+		},
+		{
+			name:     "unsupported driver",
+			logging:  &Logging{Driver: "gelf", Options: map[string]string{"gelf-address": "udp://log:12201", "tag": "web"}},
+			expected: &PodmanLogging{Driver: "journald", Options: []string{"tag=web"}},
+			errs:     2,
+		},
+		{
+			name:     "options only",
+			logging:  &Logging{Options: map[string]string{"max-size": "1m"}},
+			expected: &PodmanLogging{Driver: "k8s-file", Options: []string{"max-size=1m"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := Service{Logging: tt.logging}
+			result, errs := svc.PodmanLogging()
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("PodmanLogging() = %+v, want %+v", result, tt.expected)
+			}
+			if len(errs) != tt.errs {
+				t.Errorf("Expected %d errors, got %v", tt.errs, errs)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package types
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Tmpfs is a tmpfs mount of a service
+type Tmpfs struct {
+	Path string
+	// Options are the mount options, such as size=64m,mode=1777
+	Options string
+}
+
+// TmpfsList returns the tmpfs mounts of a service, given as a single path
+// or a list of path[:options]
+func (s *Service) TmpfsList() []Tmpfs {
+	var mounts []Tmpfs
+	for _, entry := range toStringList(s.Tmpfs) {
+		path, options, _ := strings.Cut(entry, ":")
+		mounts = append(mounts, Tmpfs{Path: path, Options: options})
+	}
+	return mounts
+}
+
+// Size returns the size= option of a tmpfs mount in bytes, or 0 if unset
+func (t Tmpfs) Size() (int64, error) {
+	for _, option := range strings.Split(t.Options, ",") {
+		if size, ok := strings.CutPrefix(option, "size="); ok {
+			return ParseByteSize(size)
+		}
+	}
+	return 0, nil
+}
+
+// Ulimit is a resource limit of a service
+type Ulimit struct {
+	Name string
+	Soft int64
+	Hard int64
+}
+
+// String returns the limit in the name=soft:hard form used by podman --ulimit
+func (u Ulimit) String() string {
+	if u.Soft == u.Hard {
+		return fmt.Sprintf("%s=%d", u.Name, u.Soft)
+	}
+	return fmt.Sprintf("%s=%d:%d", u.Name, u.Soft, u.Hard)
+}
+
+// UlimitsList returns the ulimits of a service sorted by name. A single
+// value sets both the soft and the hard limit.
+func (s *Service) UlimitsList() ([]Ulimit, error) {
+	var limits []Ulimit
+	for name, val := range s.Ulimits {
+		limit := Ulimit{Name: name}
+		if entry := toStringMap(val); entry != nil {
+			soft, err := toInt64(entry["soft"])
+			if err != nil {
+				return nil, fmt.Errorf("ulimit %s: soft: %w", name, err)
+			}
+			hard, err := toInt64(entry["hard"])
+			if err != nil {
+				return nil, fmt.Errorf("ulimit %s: hard: %w", name, err)
+			}
+			limit.Soft, limit.Hard = soft, hard
+		} else {
+			n, err := toInt64(val)
+			if err != nil {
+				return nil, fmt.Errorf("ulimit %s: %w", name, err)
+			}
+			limit.Soft, limit.Hard = n, n
+		}
+		limits = append(limits, limit)
+	}
+	sort.Slice(limits, func(i, j int) bool { return limits[i].Name < limits[j].Name })
+	return limits, nil
+}
+
+// SysctlsMap returns the sysctls of a service in map or list form
+func (s *Service) SysctlsMap() map[string]string {
+	return toKeyValueMap(s.Sysctls)
+}
+
+// ParseByteSize parses a Compose byte value: a number of bytes, optionally
+// followed by a b, k, m or g unit (kb, mb and gb are accepted too)
+func ParseByteSize(s string) (int64, error) {
+	value := strings.ToLower(strings.TrimSpace(s))
+	value = strings.TrimSuffix(value, "b")
+	multiplier := int64(1)
+	if value != "" {
+		switch value[len(value)-1] {
+		case 'k':
+			multiplier = 1 << 10
+		case 'm':
+			multiplier = 1 << 20
+		case 'g':
+			multiplier = 1 << 30
+		}
+		if multiplier > 1 {
+			value = value[:len(value)-1]
+		}
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid byte value %q", s)
+	}
+	return n * multiplier, nil
+}
+
+func toInt64(v interface{}) (int64, error) {
+	switch n := v.(type) {
+	case int:
+		return int64(n), nil
+	case int64:
+		return n, nil
+	case string:
+		return strconv.ParseInt(n, 10, 64)
+	}
+	return 0, fmt.Errorf("invalid number %v", v)
+}
@@ -0,0 +1,56 @@
+package types
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+		wantErr  bool
+	}{
+		{"1024", 1024, false},
+		{"64m", 64 << 20, false},
+		{"1gb", 1 << 30, false},
+		{"512K", 512 << 10, false},
+		{"10b", 10, false},
+		{"lots", 0, true},
+		{"-1", 0, true},
+	}
+
+	for _, tt := range tests {
+		result, err := ParseByteSize(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseByteSize(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+		}
+		if result != tt.expected {
+			t.Errorf("ParseByteSize(%q) = %d, want %d", tt.input, result, tt.expected)
+		}
+	}
+}
+
+func TestServiceUlimitsList(t *testing.T) {
+	svc := Service{Ulimits: map[string]interface{}{
+		"nproc":  65535,
+		"nofile": map[string]interface{}{"soft": 20000, "hard": 40000},
+	}}
+
+	limits, err := svc.UlimitsList()
+	if err != nil {
+		t.Fatalf("UlimitsList failed: %v", err)
+	}
+	expected := []Ulimit{{Name: "nofile", Soft: 20000, Hard: 40000}, {Name: "nproc", Soft: 65535, Hard: 65535}}
+	if !reflect.DeepEqual(limits, expected) {
+		t.Errorf("UlimitsList() = %v, want %v", limits, expected)
+	}
+	if limits[0].String() != "nofile=20000:40000" || limits[1].String() != "nproc=65535" {
+		t.Errorf("Unexpected podman ulimit strings %s, %s", limits[0], limits[1])
+	}
+
+	svc = Service{Ulimits: map[string]interface{}{"nofile": "many"}}
+	if _, err := svc.UlimitsList(); err == nil {
+		t.Error("Expected error for a non-numeric ulimit")
+	}
+}
@@ -0,0 +1,112 @@
+package types
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Restart policies of a service, as in the restart attribute
+const (
+	RestartNo            = "no"
+	RestartAlways        = "always"
+	RestartOnFailure     = "on-failure"
+	RestartUnlessStopped = "unless-stopped"
+)
+
+// RestartSettings is the restart behavior of a service
+type RestartSettings struct {
+	// Policy is one of the Restart* constants
+	Policy string
+	// MaxAttempts limits the number of restarts; 0 is unlimited
+	MaxAttempts int
+	// Delay is the time to wait between restarts
+	Delay time.Duration
+	// Window is the time over which restarts are counted
+	Window time.Duration
+}
+
+// RestartSettings returns the restart behavior of a service. As in Compose,
+// deploy.restart_policy takes precedence over restart, and containers are
+// not restarted when neither is set.
+func (s *Service) RestartSettings() (RestartSettings, error) {
+	if s.Deploy != nil && s.Deploy.RestartPolicy != nil {
+		return deployRestartSettings(*s.Deploy.RestartPolicy)
+	}
+
+	policy, count, hasCount := strings.Cut(s.Restart, ":")
+	settings := RestartSettings{Policy: policy}
+	switch policy {
+	case "":
+		settings.Policy = RestartNo
+	case RestartNo, RestartAlways, RestartUnlessStopped:
+	case RestartOnFailure:
+		if hasCount {
+			n, err := strconv.Atoi(count)
+			if err != nil || n < 0 {
+				return settings, fmt.Errorf("invalid restart retry count %q", count)
+			}
+			settings.MaxAttempts = n
+		}
+	default:
+		return settings, fmt.Errorf("invalid restart policy %q", s.Restart)
+	}
+	if hasCount && policy != RestartOnFailure {
+		return settings, fmt.Errorf("restart policy %q does not take a retry count", s.Restart)
+	}
+	return settings, nil
+}
+
+func deployRestartSettings(policy RestartPolicy) (RestartSettings, error) {
+	var settings RestartSettings
+	switch policy.Condition {
+	case "", "any":
+		settings.Policy = RestartAlways
+	case "on-failure":
+		settings.Policy = RestartOnFailure
+	case "none":
+		settings.Policy = RestartNo
+	default:
+		return settings, fmt.Errorf("invalid restart_policy condition %q", policy.Condition)
+	}
+
+	if policy.MaxAttempts < 0 {
+		return settings, fmt.Errorf("invalid restart_policy max_attempts %d", policy.MaxAttempts)
+	}
+	settings.MaxAttempts = policy.MaxAttempts
+
+	var err error
+	if settings.Delay, err = ParseDuration(policy.Delay); err != nil {
+		return settings, fmt.Errorf("restart_policy delay: %w", err)
+	}
+	if settings.Window, err = ParseDuration(policy.Window); err != nil {
+		return settings, fmt.Errorf("restart_policy window: %w", err)
+	}
+	return settings, nil
+}
+
+// StopGracePeriodDuration returns stop_grace_period and whether it is set
+func (s *Service) StopGracePeriodDuration() (time.Duration, bool, error) {
+	if s.StopGracePeriod == "" {
+		return 0, false, nil
+	}
+	d, err := ParseDuration(s.StopGracePeriod)
+	if err != nil {
+		return 0, false, fmt.Errorf("stop_grace_period: %w", err)
+	}
+	return d, true, nil
+}
+
+// ParseDuration parses a Compose duration such as 10s, 1m30s or 500ms.
+// An empty string is a zero duration.
+func ParseDuration(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return d, nil
+}
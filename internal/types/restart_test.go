@@ -0,0 +1,42 @@
+package types
+
+import (
+	"testing"
+	"time"
+)
+
+func TestServiceRestartSettings(t *testing.T) {
+	tests := []struct {
+		name     string
+		service  Service
+		expected RestartSettings
+		wantErr  bool
+	}{
+		{"default", Service{}, RestartSettings{Policy: RestartNo}, false},
+		{"unless-stopped", Service{Restart: "unless-stopped"}, RestartSettings{Policy: RestartUnlessStopped}, false},
+		{"on-failure count", Service{Restart: "on-failure:5"}, RestartSettings{Policy: RestartOnFailure, MaxAttempts: 5}, false},
+		{"invalid", Service{Restart: "sometimes"}, RestartSettings{}, true},
+		{"count on always", Service{Restart: "always:3"}, RestartSettings{}, true},
+		{
+			name: "deploy overrides restart",
+			service: Service{Restart: "always", Deploy: &Deploy{RestartPolicy: &RestartPolicy{
+				Condition: "on-failure", Delay: "5s", MaxAttempts: 3, Window: "2m",
+			}}},
+			expected: RestartSettings{Policy: RestartOnFailure, MaxAttempts: 3, Delay: 5 * time.Second, Window: 2 * time.Minute},
+		},
+		{"deploy default condition", Service{Deploy: &Deploy{RestartPolicy: &RestartPolicy{}}}, RestartSettings{Policy: RestartAlways}, false},
+		{"deploy invalid delay", Service{Deploy: &Deploy{RestartPolicy: &RestartPolicy{Delay: "soon"}}}, RestartSettings{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := tt.service.RestartSettings()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RestartSettings() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && result != tt.expected {
+				t.Errorf("RestartSettings() = %+v, want %+v", result, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package types
+
+import (
+	"slices"
+	"strings"
+)
+
+// SecurityOptions is the parsed form of a service's security_opt list
+type SecurityOptions struct {
+	NoNewPrivileges bool
+	// Seccomp is a profile path or "unconfined"
+	Seccomp string
+	// AppArmor is a profile name or "unconfined"
+	AppArmor     string
+	LabelDisable bool
+	LabelNested  bool
+	LabelType    string
+	LabelLevel   string
+	LabelUser    string
+	LabelRole    string
+	Mask         []string
+	Unmask       []string
+	// Other holds options without a dedicated field, normalized to
+	// the key=value form accepted by podman --security-opt
+	Other []string
+}
+
+// SecurityOptions parses security_opt. Both the key:value and the key=value
+// forms are accepted, as in Compose.
+func (s *Service) SecurityOptions() SecurityOptions {
+	var opts SecurityOptions
+
+	for _, opt := range s.SecurityOpt {
+		key, val := splitSecurityOpt(opt)
+		switch key {
+		case "no-new-privileges":
+			opts.NoNewPrivileges = val == "" || val == "true"
+		case "seccomp":
+			opts.Seccomp = val
+		case "apparmor":
+			opts.AppArmor = val
+		case "mask":
+			opts.Mask = append(opts.Mask, val)
+		case "unmask":
+			opts.Unmask = append(opts.Unmask, val)
+		case "label":
+			labelKey, labelVal := splitSecurityOpt(val)
+			switch labelKey {
+			case "disable":
+				opts.LabelDisable = true
+			case "nested":
+				opts.LabelNested = true
+			case "type":
+				opts.LabelType = labelVal
+			case "level":
+				opts.LabelLevel = labelVal
+			case "user":
+				opts.LabelUser = labelVal
+			case "role":
+				opts.LabelRole = labelVal
+			default:
+				opts.Other = append(opts.Other, key+"="+val)
+			}
+		default:
+			if val == "" {
+				opts.Other = append(opts.Other, key)
+			} else {
+				opts.Other = append(opts.Other, key+"="+val)
+			}
+		}
+	}
+
+	return opts
+}
+
+// Capabilities returns cap_add and cap_drop with normalized names, see
+// NormalizeCapability, and without duplicates
+func (s *Service) Capabilities() (add, drop []string) {
+	return normalizeCapabilities(s.CapAdd), normalizeCapabilities(s.CapDrop)
+}
+
+// NormalizeCapability returns a capability name in the upper-case CAP_
+// form used by Podman. ALL is kept as is.
+func NormalizeCapability(name string) string {
+	name = strings.ToUpper(strings.TrimSpace(name))
+	if name == "ALL" || strings.HasPrefix(name, "CAP_") {
+		return name
+	}
+	return "CAP_" + name
+}
+
+func normalizeCapabilities(names []string) []string {
+	var caps []string
+	for _, name := range names {
+		if cap := NormalizeCapability(name); !slices.Contains(caps, cap) {
+			caps = append(caps, cap)
+		}
+	}
+	return caps
+}
+
+// splitSecurityOpt splits an option at the first ':' or '='
+func splitSecurityOpt(opt string) (string, string) {
+	if idx := strings.IndexAny(opt, ":="); idx >= 0 {
+		return opt[:idx], opt[idx+1:]
+	}
+	return opt, ""
+}
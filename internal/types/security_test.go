@@ -0,0 +1,53 @@
+package types
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestServiceSecurityOptions(t *testing.T) {
+	svc := Service{SecurityOpt: []string{
+		"no-new-privileges:true",
+		"seccomp=./seccomp.json",
+		"apparmor:docker-default",
+		"label:type:container_runtime_t",
+		"label=level:s0:c100,c200",
+		"label:disable",
+		"mask=/proc/acpi",
+		"systempaths=unconfined",
+	}}
+
+	expected := SecurityOptions{
+		NoNewPrivileges: true,
+		Seccomp:         "./seccomp.json",
+		AppArmor:        "docker-default",
+		LabelDisable:    true,
+		LabelType:       "container_runtime_t",
+		LabelLevel:      "s0:c100,c200",
+		Mask:            []string{"/proc/acpi"},
+		Other:           []string{"systempaths=unconfined"},
+	}
+	if result := svc.SecurityOptions(); !reflect.DeepEqual(result, expected) {
+		t.Errorf("SecurityOptions() = %+v, want %+v", result, expected)
+	}
+
+	svc = Service{SecurityOpt: []string{"no-new-privileges:false"}}
+	if svc.SecurityOptions().NoNewPrivileges {
+		t.Error("no-new-privileges:false should not be enabled")
+	}
+}
+
+func TestServiceCapabilities(t *testing.T) {
+	svc := Service{
+		CapAdd:  []string{"net_admin", "CAP_SYS_TIME", "NET_ADMIN"},
+		CapDrop: []string{"all"},
+	}
+
+	add, drop := svc.Capabilities()
+	if !reflect.DeepEqual(add, []string{"CAP_NET_ADMIN", "CAP_SYS_TIME"}) {
+		t.Errorf("Unexpected cap_add %v", add)
+	}
+	if !reflect.DeepEqual(drop, []string{"ALL"}) {
+		t.Errorf("Unexpected cap_drop %v", drop)
+	}
+}
@@ -0,0 +1,207 @@
+package types
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Notify modes of x-podman.notify
+const (
+	NotifyTrue    = "true"
+	NotifyFalse   = "false"
+	NotifyHealthy = "healthy"
+)
+
+// Rootless port handlers of x-podman.port-handler
+const (
+	PortHandlerRootlessPort = "rootlessport"
+	PortHandlerSlirp4netns  = "slirp4netns"
+)
+
+// Quadlet sections x-podman.quadlet can add raw keys to. The service
+// sections are accepted at both levels; the others only at the top level,
+// where they apply to every unit of that type.
+var (
+	serviceQuadletSections  = []string{"Unit", "Container", "Service", "Install"}
+	topLevelQuadletSections = []string{"Unit", "Container", "Service", "Install", "Kube", "Network", "Volume", "Image"}
+)
+
+// XPodman holds the x-podman extension with settings Compose has no
+// attribute for. At the top level it sets defaults for all services.
+type XPodman struct {
+	// UserNS is the user namespace mode, e.g. keep-id; it overrides userns_mode
+	UserNS string `yaml:"userns,omitempty"`
+	// Pod is the Quadlet .pod unit the container joins
+	Pod string `yaml:"pod,omitempty"`
+	// PodmanArgs and GlobalArgs are passed to podman run and podman as is
+	PodmanArgs []string `yaml:"podman-args,omitempty"`
+	GlobalArgs []string `yaml:"global-args,omitempty"`
+	// Notify is true, false or healthy, as Notify= of Quadlet
+	Notify string `yaml:"notify,omitempty"`
+	// Relabel is the SELinux relabel mode, z or Z, of bind mounts
+	Relabel string `yaml:"relabel,omitempty"`
+	// PortHandler is the rootless port handler, rootlessport or slirp4netns
+	PortHandler string `yaml:"port-handler,omitempty"`
+	// AutoUpdate is registry, local or disabled
+	AutoUpdate string `yaml:"auto-update,omitempty"`
+	// Quadlet holds raw keys by Quadlet section, e.g. Container or Service.
+	// A list value repeats the key.
+	Quadlet map[string]map[string]interface{} `yaml:"quadlet,omitempty"`
+
+	// Unknown collects keys outside the schema so Validate can reject them
+	Unknown map[string]interface{} `yaml:",inline"`
+}
+
+// Validate checks the x-podman block against its schema. topLevel selects
+// the Quadlet sections allowed in the top-level block.
+func (x *XPodman) Validate(topLevel bool) error {
+	if x == nil {
+		return nil
+	}
+	if len(x.Unknown) > 0 {
+		keys := make([]string, 0, len(x.Unknown))
+		for key := range x.Unknown {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		return fmt.Errorf("x-podman: unknown key %q", keys[0])
+	}
+
+	switch x.Notify {
+	case "", NotifyTrue, NotifyFalse, NotifyHealthy:
+	default:
+		return fmt.Errorf("x-podman: invalid notify %q (use true, false or healthy)", x.Notify)
+	}
+	switch x.Relabel {
+	case "", "z", "Z":
+	default:
+		return fmt.Errorf("x-podman: invalid relabel %q (use z or Z)", x.Relabel)
+	}
+	switch x.PortHandler {
+	case "", PortHandlerRootlessPort, PortHandlerSlirp4netns:
+	default:
+		return fmt.Errorf("x-podman: invalid port-handler %q (use rootlessport or slirp4netns)", x.PortHandler)
+	}
+	if err := ValidateAutoUpdate(x.AutoUpdate); err != nil {
+		return fmt.Errorf("x-podman: %w", err)
+	}
+
+	sections := serviceQuadletSections
+	if topLevel {
+		sections = topLevelQuadletSections
+	}
+	for section, keys := range x.Quadlet {
+		if !slices.Contains(sections, section) {
+			return fmt.Errorf("x-podman: quadlet section %q is not supported here (use %s)", section, strings.Join(sections, ", "))
+		}
+		for key, value := range keys {
+			if key == "" || strings.ContainsAny(key, "= \t") {
+				return fmt.Errorf("x-podman: invalid quadlet key %q in %s", key, section)
+			}
+			if _, ok := rawValues(value); !ok {
+				return fmt.Errorf("x-podman: quadlet key %s in %s must be a scalar or a list of scalars", key, section)
+			}
+		}
+	}
+	return nil
+}
+
+// QuadletEntries returns the raw Key=Value lines of a Quadlet section,
+// sorted by key
+func (x *XPodman) QuadletEntries(section string) []string {
+	if x == nil {
+		return nil
+	}
+	keys := x.Quadlet[section]
+	names := make([]string, 0, len(keys))
+	for key := range keys {
+		names = append(names, key)
+	}
+	sort.Strings(names)
+
+	var entries []string
+	for _, key := range names {
+		values, _ := rawValues(keys[key])
+		for _, value := range values {
+			entries = append(entries, key+"="+value)
+		}
+	}
+	return entries
+}
+
+// rawValues returns the values of a raw Quadlet key, which is a scalar or
+// a list of scalars
+func rawValues(value interface{}) ([]string, bool) {
+	switch v := value.(type) {
+	case nil:
+		return []string{""}, true
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			switch item.(type) {
+			case []interface{}, map[string]interface{}:
+				return nil, false
+			}
+			values = append(values, fmt.Sprint(item))
+		}
+		return values, true
+	case map[string]interface{}:
+		return nil, false
+	default:
+		return []string{fmt.Sprint(v)}, true
+	}
+}
+
+// WithDefaults returns x completed with the top-level block defaults:
+// settings x leaves unset are taken from defaults, argument lists are
+// appended to those of defaults and raw keys of x replace those of
+// defaults. Sections only valid at the top level are not inherited.
+func (x *XPodman) WithDefaults(defaults *XPodman) *XPodman {
+	if defaults == nil {
+		return x
+	}
+	merged := &XPodman{}
+	if x != nil {
+		*merged = *x
+	}
+
+	merged.UserNS = cmp.Or(merged.UserNS, defaults.UserNS)
+	merged.Pod = cmp.Or(merged.Pod, defaults.Pod)
+	merged.Notify = cmp.Or(merged.Notify, defaults.Notify)
+	merged.Relabel = cmp.Or(merged.Relabel, defaults.Relabel)
+	merged.PortHandler = cmp.Or(merged.PortHandler, defaults.PortHandler)
+	merged.AutoUpdate = cmp.Or(merged.AutoUpdate, defaults.AutoUpdate)
+	merged.PodmanArgs = append(slices.Clone(defaults.PodmanArgs), merged.PodmanArgs...)
+	merged.GlobalArgs = append(slices.Clone(defaults.GlobalArgs), merged.GlobalArgs...)
+
+	var own map[string]map[string]interface{}
+	if x != nil {
+		own = x.Quadlet
+	}
+	merged.Quadlet = nil
+	for _, section := range serviceQuadletSections {
+		keys := make(map[string]interface{})
+		maps.Copy(keys, defaults.Quadlet[section])
+		maps.Copy(keys, own[section])
+		if len(keys) > 0 {
+			if merged.Quadlet == nil {
+				merged.Quadlet = make(map[string]map[string]interface{})
+			}
+			merged.Quadlet[section] = keys
+		}
+	}
+	return merged
+}
+
+// UserNSMode returns the user namespace mode of a service: x-podman.userns
+// or userns_mode
+func (s *Service) UserNSMode() string {
+	if s.XPodman != nil && s.XPodman.UserNS != "" {
+		return s.XPodman.UserNS
+	}
+	return s.UsernsMode
+}
@@ -0,0 +1,105 @@
+package types
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestXPodmanValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		xpodman  *XPodman
+		topLevel bool
+		wantErr  bool
+	}{
+		{"nil", nil, false, false},
+		{"valid", &XPodman{UserNS: "keep-id", Notify: "healthy", Relabel: "Z", PortHandler: "slirp4netns"}, false, false},
+		{"unknown key", &XPodman{Unknown: map[string]interface{}{"usrns": "keep-id"}}, false, true},
+		{"bad relabel", &XPodman{Relabel: "shared"}, false, true},
+		{"bad port handler", &XPodman{PortHandler: "pasta"}, false, true},
+		{"bad auto-update", &XPodman{AutoUpdate: "nightly"}, false, true},
+		{"service section", &XPodman{Quadlet: map[string]map[string]interface{}{"Service": {"Restart": "always"}}}, false, false},
+		{"top-level section in service", &XPodman{Quadlet: map[string]map[string]interface{}{"Network": {"Internal": true}}}, false, true},
+		{"top-level section", &XPodman{Quadlet: map[string]map[string]interface{}{"Network": {"Internal": true}}}, true, false},
+		{"unknown section", &XPodman{Quadlet: map[string]map[string]interface{}{"Socket": {"ListenStream": 80}}}, true, true},
+		{"bad key", &XPodman{Quadlet: map[string]map[string]interface{}{"Container": {"Mask=": "/proc"}}}, false, true},
+		{"nested value", &XPodman{Quadlet: map[string]map[string]interface{}{"Container": {"Mask": map[string]interface{}{"a": 1}}}}, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.xpodman.Validate(tt.topLevel)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestXPodmanQuadletEntries(t *testing.T) {
+	x := &XPodman{Quadlet: map[string]map[string]interface{}{
+		"Container": {
+			"Mask":          []interface{}{"/proc/a", "/proc/b"},
+			"ReadOnlyTmpfs": false,
+			"PidsLimit":     100,
+		},
+	}}
+
+	expected := []string{"Mask=/proc/a", "Mask=/proc/b", "PidsLimit=100", "ReadOnlyTmpfs=false"}
+	if entries := x.QuadletEntries("Container"); !slices.Equal(entries, expected) {
+		t.Errorf("QuadletEntries() = %v, want %v", entries, expected)
+	}
+	if entries := x.QuadletEntries("Service"); len(entries) != 0 {
+		t.Errorf("QuadletEntries() of an empty section = %v", entries)
+	}
+}
+
+func TestXPodmanWithDefaults(t *testing.T) {
+	defaults := &XPodman{
+		UserNS:     "keep-id",
+		AutoUpdate: "registry",
+		PodmanArgs: []string{"--memory-swap=1g"},
+		Quadlet: map[string]map[string]interface{}{
+			"Service": {"Restart": "always", "RestartSec": 5},
+			"Network": {"Internal": true},
+		},
+	}
+	service := &XPodman{
+		AutoUpdate: "disabled",
+		PodmanArgs: []string{"--pids-limit=100"},
+		Quadlet:    map[string]map[string]interface{}{"Service": {"Restart": "on-failure"}},
+	}
+
+	merged := service.WithDefaults(defaults)
+	if merged.UserNS != "keep-id" || merged.AutoUpdate != "disabled" {
+		t.Errorf("Expected inherited userns and own auto-update, got %+v", merged)
+	}
+	if expected := []string{"--memory-swap=1g", "--pids-limit=100"}; !slices.Equal(merged.PodmanArgs, expected) {
+		t.Errorf("PodmanArgs = %v, want %v", merged.PodmanArgs, expected)
+	}
+	if expected := []string{"Restart=on-failure", "RestartSec=5"}; !slices.Equal(merged.QuadletEntries("Service"), expected) {
+		t.Errorf("Service entries = %v, want %v", merged.QuadletEntries("Service"), expected)
+	}
+	if _, ok := merged.Quadlet["Network"]; ok {
+		t.Error("Top-level only sections must not be inherited")
+	}
+	if len(defaults.PodmanArgs) != 1 || defaults.Quadlet["Service"]["Restart"] != "always" {
+		t.Errorf("WithDefaults modified the defaults: %+v", defaults)
+	}
+
+	var unset *XPodman
+	if merged := unset.WithDefaults(defaults); merged.UserNS != "keep-id" {
+		t.Errorf("Expected a service without x-podman to inherit the defaults, got %+v", merged)
+	}
+}
+
+func TestServiceUserNSMode(t *testing.T) {
+	svc := Service{UsernsMode: "host"}
+	if mode := svc.UserNSMode(); mode != "host" {
+		t.Errorf("UserNSMode() = %q, want host", mode)
+	}
+	svc.XPodman = &XPodman{UserNS: "keep-id"}
+	if mode := svc.UserNSMode(); mode != "keep-id" {
+		t.Errorf("UserNSMode() = %q, want keep-id", mode)
+	}
+}
@@ -0,0 +1,40 @@
+package kube
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kad/compose2podman/internal/types"
+)
+
+// envConfigMapName returns the name of the ConfigMap holding a service's
+// env_file variables
+func (g *Generator) envConfigMapName(service string) string {
+	return pathToVolumeName(fmt.Sprintf("%s-%s-env", g.podName, service))
+}
+
+// ConfigMaps returns a ConfigMap document per service with env_file
+// variables when they are referenced instead of inlined, for podman kube
+// play --configmap when they are not part of Generate
+func (g *Generator) ConfigMaps() string {
+	if g.opts.EnvFileMode != types.EnvFileReference {
+		return ""
+	}
+
+	var sb strings.Builder
+	for _, name := range g.serviceNames() {
+		service := g.compose.Services[name]
+		if len(service.EnvFileVars) == 0 {
+			continue
+		}
+
+		sb.WriteString("apiVersion: v1\n")
+		sb.WriteString("kind: ConfigMap\n")
+		sb.WriteString("metadata:\n")
+		fmt.Fprintf(&sb, "  name: %s\n", g.envConfigMapName(name))
+		writeStringMap(&sb, "  ", "labels", g.compose.ProjectLabels())
+		writeStringMap(&sb, "", "data", service.EnvFileVars)
+		sb.WriteString("---\n")
+	}
+	return sb.String()
+}
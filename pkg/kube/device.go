@@ -0,0 +1,71 @@
+package kube
+
+import (
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/kad/compose2podman/internal/types"
+)
+
+// deviceMounts returns the host devices of a service that can be passed to
+// a pod as hostPath volumes. CDI devices, device permissions and
+// device_cgroup_rules have no pod equivalent and are reported as warnings.
+func (g *Generator) deviceMounts(name string, service types.Service) []types.Device {
+	var devices []types.Device
+	for _, device := range service.DevicesList() {
+		if device.IsCDI() {
+			g.warnf("service %s: CDI device %s cannot be requested in a pod and was dropped", name, device.Source)
+			continue
+		}
+		if device.Permissions != "" && device.Permissions != "rwm" {
+			g.warnf("service %s: permissions %s of device %s cannot be set in a pod; the device is mounted with full access", name, device.Permissions, device.Source)
+		}
+		if deviceHostPathType(device.Source) == "" {
+			g.warnf("service %s: device %s is not a single device under /dev; it is mounted without a device type and may not be accessible", name, device.Source)
+		}
+		devices = append(devices, device)
+	}
+
+	cdi, errs := service.CDIDevices()
+	for _, device := range cdi {
+		g.warnf("service %s: CDI device %s cannot be requested in a pod and was dropped", name, device)
+	}
+	for _, err := range errs {
+		g.warnf("service %s: %v; dropped", name, err)
+	}
+	for _, rule := range service.DeviceCgroupRules {
+		g.warnf("service %s: device_cgroup_rules %q cannot be set in a pod and was dropped", name, rule)
+	}
+
+	return devices
+}
+
+// Device paths whose hostPath type is not CharDevice: block devices and
+// directories of devices, which a hostPath cannot mark as a device
+var (
+	blockDevicePattern = regexp.MustCompile(`^/dev/(sd[a-z]+|vd[a-z]+|nvme[0-9]+n[0-9]+|loop[0-9]+)(p?[0-9]+)?$`)
+	deviceDirectories  = []string{
+		"/dev/bus/usb", "/dev/disk", "/dev/dri", "/dev/input", "/dev/mapper",
+		"/dev/net", "/dev/snd", "/dev/vfio",
+	}
+)
+
+// deviceHostPathType returns the hostPath type of a device path, so podman
+// kube play passes it as a device rather than a bind mount: BlockDevice for
+// disks and loop devices, CharDevice for any other device under /dev, and ""
+// for directories and paths outside /dev, whose type cannot be set. The
+// converting host is not inspected, as the target host may differ and the
+// output must not depend on where it is generated.
+func deviceHostPathType(device string) string {
+	device = path.Clean(device)
+	switch {
+	case !strings.HasPrefix(device, "/dev/") || slices.Contains(deviceDirectories, device):
+		return ""
+	case blockDevicePattern.MatchString(device):
+		return "BlockDevice"
+	default:
+		return "CharDevice"
+	}
+}
@@ -0,0 +1,85 @@
+package kube
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/kad/compose2podman/internal/types"
+)
+
+// extraHostAliases groups the extra_hosts of all services by IP, in order of
+// first appearance. The pod shares one /etc/hosts, so entries of all services
+// are merged.
+func (g *Generator) extraHostAliases() (map[string][]string, []string) {
+	hosts := make(map[string][]string)
+	var ips []string
+	for _, name := range g.serviceNames() {
+		service := g.compose.Services[name]
+		for _, host := range service.ExtraHostsList() {
+			if host.IP == types.HostGateway {
+				g.warnf("service %s: extra_hosts %s:%s cannot be expressed in hostAliases; use host.containers.internal, which Podman adds to every container", name, host.Host, host.IP)
+				continue
+			}
+			if _, ok := hosts[host.IP]; !ok {
+				ips = append(ips, host.IP)
+			}
+			if !slices.Contains(hosts[host.IP], host.Host) {
+				hosts[host.IP] = append(hosts[host.IP], host.Host)
+			}
+		}
+	}
+	return hosts, ips
+}
+
+// writeDNSConfig writes the pod dnsConfig from dns, dns_search and dns_opt.
+// A pod has a single resolv.conf, so the settings of all services are
+// merged.
+func (g *Generator) writeDNSConfig(sb *strings.Builder) {
+	var servers, searches, options []string
+	appendNew := func(list []string, items ...string) []string {
+		for _, item := range items {
+			if !slices.Contains(list, item) {
+				list = append(list, item)
+			}
+		}
+		return list
+	}
+
+	for _, name := range g.serviceNames() {
+		service := g.compose.Services[name]
+		servers = appendNew(servers, service.DNSList()...)
+		searches = appendNew(searches, service.DNSSearchList()...)
+		options = appendNew(options, service.DNSOpt...)
+		if service.Domainname != "" {
+			g.warnf("service %s: domainname cannot be set in a pod and was dropped", name)
+		}
+	}
+
+	if len(servers) == 0 && len(searches) == 0 && len(options) == 0 {
+		return
+	}
+	sb.WriteString("  dnsConfig:\n")
+	if len(servers) > 0 {
+		sb.WriteString("    nameservers:\n")
+		for _, server := range servers {
+			fmt.Fprintf(sb, "    - %s\n", quote(server))
+		}
+	}
+	if len(searches) > 0 {
+		sb.WriteString("    searches:\n")
+		for _, domain := range searches {
+			fmt.Fprintf(sb, "    - %s\n", domain)
+		}
+	}
+	if len(options) > 0 {
+		sb.WriteString("    options:\n")
+		for _, option := range options {
+			key, val, found := strings.Cut(option, ":")
+			fmt.Fprintf(sb, "    - name: %s\n", key)
+			if found {
+				fmt.Fprintf(sb, "      value: %s\n", quote(val))
+			}
+		}
+	}
+}
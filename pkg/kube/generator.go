@@ -5,6 +5,7 @@ package kube
 import (
 	"fmt"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/kad/compose2podman/internal/types"
@@ -16,28 +17,72 @@ type volumeInfo struct {
 	hostPath string // empty if it's a named volume
 	isPath   bool   // true if it's a host path (absolute or relative)
 	hostType string // Kubernetes hostPath type: DirectoryOrCreate, FileOrCreate, etc.
+
+	memory    bool   // true for a memory-backed emptyDir (tmpfs, shm_size)
+	sizeLimit string // emptyDir size limit in bytes, empty for no limit
+}
+
+// Options configures optional behavior of the Kubernetes generator
+type Options struct {
+	// EnvFileMode selects whether env_file variables are written into env
+	// (default) or into a ConfigMap referenced with envFrom
+	EnvFileMode types.EnvFileMode
+
+	// LookupEnv resolves environment variables declared without a value,
+	// typically os.LookupEnv. Nil resolves nothing.
+	LookupEnv func(string) (string, bool)
+
+	// AutoUpdate is the podman auto-update policy, registry or local, of
+	// services that do not set x-podman.auto-update. Empty disables it.
+	AutoUpdate string
+
+	// SeparateConfigMaps leaves the env_file ConfigMaps out of Generate so
+	// they can be written to their own file, see ConfigMaps
+	SeparateConfigMaps bool
 }
 
 // Generator generates Kubernetes YAML for podman play kube
 type Generator struct {
-	compose *types.ComposeFile
-	podName string
+	compose  *types.ComposeFile
+	podName  string
+	opts     Options
+	warnings []string
 }
 
 // NewGenerator creates a new Kubernetes YAML generator
+// The pod is named after the project unless podName is given.
 func NewGenerator(compose *types.ComposeFile, podName string) *Generator {
+	return NewGeneratorWithOptions(compose, podName, Options{})
+}
+
+// NewGeneratorWithOptions creates a new Kubernetes YAML generator with options
+func NewGeneratorWithOptions(compose *types.ComposeFile, podName string, opts Options) *Generator {
+	if podName == "" {
+		podName = compose.Name
+	}
 	if podName == "" {
 		podName = "compose-pod"
 	}
 	return &Generator{
 		compose: compose,
 		podName: podName,
+		opts:    opts,
 	}
 }
 
 // Generate creates Kubernetes Pod YAML
 func (g *Generator) Generate() (string, error) {
 	var sb strings.Builder
+	g.warnings = nil
+
+	networkMode, err := g.podNetworkMode()
+	if err != nil {
+		return "", err
+	}
+	namespaces, err := g.podNamespaceSettings()
+	if err != nil {
+		return "", err
+	}
 
 	// Track volumes used by containers
 	usedVolumes := make(map[string]*volumeInfo)
@@ -47,16 +92,28 @@ func (g *Generator) Generate() (string, error) {
 	sb.WriteString("metadata:\n")
 	sb.WriteString(fmt.Sprintf("  name: %s\n", g.podName))
 
-	// Add labels
-	sb.WriteString("  labels:\n")
-	sb.WriteString("    app: compose2podman\n")
+	// Add labels and annotations
+	labels, annotations, err := g.podMetadata()
+	if err != nil {
+		return "", err
+	}
+	writeStringMap(&sb, "  ", "labels", labels)
+	writeStringMap(&sb, "  ", "annotations", annotations)
 
 	sb.WriteString("spec:\n")
+	if networkMode == "host" {
+		sb.WriteString("  hostNetwork: true\n")
+	}
+	namespaces.write(&sb)
+	g.writePodSecurityContext(&sb)
+	if err := g.writeTerminationGracePeriod(&sb); err != nil {
+		return "", err
+	}
 	sb.WriteString("  containers:\n")
 
 	// Generate containers from services
-	for name, service := range g.compose.Services {
-		if err := g.generateContainer(&sb, name, service, usedVolumes); err != nil {
+	for _, name := range g.serviceNames() {
+		if err := g.generateContainer(&sb, name, g.compose.Services[name], usedVolumes); err != nil {
 			return "", err
 		}
 	}
@@ -64,40 +121,139 @@ func (g *Generator) Generate() (string, error) {
 	// Add volumes section
 	if len(usedVolumes) > 0 {
 		sb.WriteString("  volumes:\n")
-		for _, volInfo := range usedVolumes {
+		for _, volName := range sortedKeys(usedVolumes) {
+			volInfo := usedVolumes[volName]
 			sb.WriteString(fmt.Sprintf("  - name: %s\n", volInfo.name))
-			if volInfo.isPath {
+			if volInfo.memory {
+				sb.WriteString("    emptyDir:\n")
+				sb.WriteString("      medium: Memory\n")
+				if volInfo.sizeLimit != "" {
+					sb.WriteString(fmt.Sprintf("      sizeLimit: %s\n", volInfo.sizeLimit))
+				}
+			} else if volInfo.isPath {
 				// Use hostPath for actual paths
 				sb.WriteString("    hostPath:\n")
 				sb.WriteString(fmt.Sprintf("      path: %s\n", volInfo.hostPath))
-				sb.WriteString(fmt.Sprintf("      type: %s\n", volInfo.hostType))
+				if volInfo.hostType != "" {
+					sb.WriteString(fmt.Sprintf("      type: %s\n", volInfo.hostType))
+				}
 			} else {
 				// Use PVC for named volumes
 				sb.WriteString("    persistentVolumeClaim:\n")
-				sb.WriteString(fmt.Sprintf("      claimName: %s\n", volInfo.name))
+				sb.WriteString(fmt.Sprintf("      claimName: %s\n", g.claimName(volInfo.name)))
 			}
 		}
 	}
 
+	g.writeHostAliases(&sb)
+	g.writeDNSConfig(&sb)
+
 	// Add restart policy
-	sb.WriteString("  restartPolicy: Always\n")
+	restartPolicy, err := g.podRestartPolicy()
+	if err != nil {
+		return "", err
+	}
+	fmt.Fprintf(&sb, "  restartPolicy: %s\n", restartPolicy)
 
-	return sb.String(), nil
+	configMaps := ""
+	if !g.opts.SeparateConfigMaps {
+		configMaps = g.ConfigMaps()
+	}
+	return g.generateClaims(usedVolumes) + configMaps + sb.String(), nil
 }
 
-func (g *Generator) generateContainer(sb *strings.Builder, name string, service types.Service, usedVolumes map[string]*volumeInfo) error {
-	containerName := name
-	if service.ContainerName != "" {
-		containerName = service.ContainerName
+// PodName returns the name of the generated pod
+func (g *Generator) PodName() string {
+	return g.podName
+}
+
+// Warnings returns the conversion problems found by the last Generate, such
+// as Compose settings that cannot be expressed in a pod and were dropped
+func (g *Generator) Warnings() []string {
+	return g.warnings
+}
+
+func (g *Generator) warnf(format string, args ...interface{}) {
+	g.warnings = append(g.warnings, fmt.Sprintf(format, args...))
+}
+
+// writeHostAliases makes service names and network aliases resolve to the
+// pod. All containers share the pod's network namespace, so anything a
+// service could reach by name under Compose is reachable on localhost.
+func (g *Generator) writeHostAliases(sb *strings.Builder) {
+	seen := make(map[string]bool)
+	var hostnames []string
+	add := func(hostname string) {
+		if !seen[hostname] {
+			seen[hostname] = true
+			hostnames = append(hostnames, hostname)
+		}
 	}
 
-	fmt.Fprintf(sb, "  - name: %s\n", containerName)
+	for _, name := range g.serviceNames() {
+		service := g.compose.Services[name]
+		add(name)
+		for _, attachment := range service.NetworkAttachments() {
+			for _, alias := range attachment.Aliases {
+				add(alias)
+			}
+			if attachment.IPv4Address != "" || attachment.IPv6Address != "" || attachment.MacAddress != "" {
+				g.warnf("service %s: static addresses on network %s cannot be set in a pod; use podman kube play --ip/--mac-address", name, attachment.Name)
+			}
+			if len(attachment.LinkLocalIPs) > 0 {
+				g.warnf("service %s: link_local_ips on network %s are not supported and were dropped", name, attachment.Name)
+			}
+		}
+	}
+
+	extra, ips := g.extraHostAliases()
+	if len(hostnames) == 0 && len(ips) == 0 {
+		return
+	}
+
+	sb.WriteString("  hostAliases:\n")
+	if len(hostnames) > 0 {
+		sb.WriteString("  - ip: 127.0.0.1\n")
+		sb.WriteString("    hostnames:\n")
+		for _, hostname := range hostnames {
+			fmt.Fprintf(sb, "    - %s\n", hostname)
+		}
+	}
+	for _, ip := range ips {
+		fmt.Fprintf(sb, "  - ip: %s\n", quote(ip))
+		sb.WriteString("    hostnames:\n")
+		for _, hostname := range extra[ip] {
+			fmt.Fprintf(sb, "    - %s\n", hostname)
+		}
+	}
+}
+
+// serviceNames returns the service names in lexical order so that the
+// generated YAML is stable across runs
+func (g *Generator) serviceNames() []string {
+	return sortedKeys(g.compose.Services)
+}
+
+// claimName returns the PVC name of a named volume. Volumes declared at the
+// top level use the project-scoped Podman volume name.
+func (g *Generator) claimName(volume string) string {
+	if _, declared := g.compose.Volumes[volume]; declared {
+		return g.compose.VolumeName(volume)
+	}
+	return volume
+}
+
+func (g *Generator) generateContainer(sb *strings.Builder, name string, service types.Service, usedVolumes map[string]*volumeInfo) error {
+	fmt.Fprintf(sb, "  - name: %s\n", containerName(name, service))
 
 	if service.Image != "" {
 		fmt.Fprintf(sb, "    image: %s\n", service.Image)
 	} else {
 		return fmt.Errorf("service %s: image is required (build not supported)", name)
 	}
+	if err := g.writeImagePolicy(sb, name, service); err != nil {
+		return err
+	}
 
 	// Command
 	if cmd := service.CommandList(); len(cmd) > 0 {
@@ -115,13 +271,26 @@ func (g *Generator) generateContainer(sb *strings.Builder, name string, service
 		}
 	}
 
-	// Environment variables
-	env := service.EnvironmentMap()
+	// Environment variables; env takes precedence over envFrom, matching
+	// Compose where environment overrides env_file
+	reference := g.opts.EnvFileMode == types.EnvFileReference
+	env, unresolved := service.ResolveEnvironment(!reference, g.opts.LookupEnv)
+	if reference && len(service.EnvFileVars) > 0 {
+		sb.WriteString("    envFrom:\n")
+		sb.WriteString("    - configMapRef:\n")
+		fmt.Fprintf(sb, "        name: %s\n", g.envConfigMapName(name))
+	}
+	for _, key := range unresolved {
+		if _, ok := service.EnvFileVars[key]; ok && reference {
+			continue
+		}
+		g.warnf("service %s: environment variable %s has no value and is not set; omitted", name, key)
+	}
 	if len(env) > 0 {
 		sb.WriteString("    env:\n")
-		for key, val := range env {
+		for _, key := range sortedKeys(env) {
 			fmt.Fprintf(sb, "    - name: %s\n", key)
-			fmt.Fprintf(sb, "      value: \"%s\"\n", val)
+			fmt.Fprintf(sb, "      value: %s\n", quote(env[key]))
 		}
 	}
 
@@ -137,8 +306,14 @@ func (g *Generator) generateContainer(sb *strings.Builder, name string, service
 		}
 	}
 
-	// Volume mounts
-	if len(service.Volumes) > 0 {
+	// Volume mounts; host devices are mounted as hostPath volumes and
+	// tmpfs mounts as memory-backed emptyDir volumes
+	devices := g.deviceMounts(name, service)
+	memory, err := g.memoryMounts(name, service)
+	if err != nil {
+		return err
+	}
+	if len(service.Volumes) > 0 || len(devices) > 0 || len(memory) > 0 {
 		sb.WriteString("    volumeMounts:\n")
 		for _, vol := range service.Volumes {
 			mountPath, volumeName, hostPath, isPath := parseVolume(vol)
@@ -156,6 +331,28 @@ func (g *Generator) generateContainer(sb *strings.Builder, name string, service
 				}
 			}
 		}
+		for _, device := range devices {
+			volumeName := pathToVolumeName(device.Source)
+			fmt.Fprintf(sb, "    - name: %s\n", volumeName)
+			fmt.Fprintf(sb, "      mountPath: %s\n", device.Target)
+			if _, exists := usedVolumes[volumeName]; !exists {
+				usedVolumes[volumeName] = &volumeInfo{
+					name:     volumeName,
+					hostPath: device.Source,
+					isPath:   true,
+					hostType: deviceHostPathType(device.Source),
+				}
+			}
+		}
+		for _, mount := range memory {
+			fmt.Fprintf(sb, "    - name: %s\n", mount.volume)
+			fmt.Fprintf(sb, "      mountPath: %s\n", mount.path)
+			usedVolumes[mount.volume] = &volumeInfo{
+				name:      mount.volume,
+				memory:    true,
+				sizeLimit: mount.sizeLimit,
+			}
+		}
 	}
 
 	// Working directory
@@ -163,27 +360,38 @@ func (g *Generator) generateContainer(sb *strings.Builder, name string, service
 		fmt.Fprintf(sb, "    workingDir: %s\n", service.WorkingDir)
 	}
 
-	// Security context
-	if service.User != "" || service.Privileged {
-		sb.WriteString("    securityContext:\n")
-		if service.User != "" {
-			// Parse user:group format
-			uid, gid := parseUser(service.User)
-			if uid != "" {
-				fmt.Fprintf(sb, "      runAsUser: %s\n", uid)
-			}
-			if gid != "" {
-				fmt.Fprintf(sb, "      runAsGroup: %s\n", gid)
-			}
-		}
-		if service.Privileged {
-			sb.WriteString("      privileged: true\n")
-		}
+	// Terminal
+	if service.Tty {
+		sb.WriteString("    tty: true\n")
+	}
+	if service.StdinOpen {
+		sb.WriteString("    stdin: true\n")
+	}
+	if service.Cgroup != "" || service.CgroupParent != "" {
+		g.warnf("service %s: cgroup and cgroup_parent cannot be set in a pod and were dropped", name)
 	}
 
+	// Lifecycle
+	if err := g.writeLifecycle(sb, name, service); err != nil {
+		return err
+	}
+
+	// Security context
+	g.writeSecurityContext(sb, name, service)
+
 	return nil
 }
 
+// sortedKeys returns the keys of m in lexical order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 // parsePort parses Docker Compose port format (host:container, ip:host:container or container)
 func parsePort(port string) (containerPort, hostPort string) {
 	parts := strings.Split(port, ":")
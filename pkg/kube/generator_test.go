@@ -1,6 +1,7 @@
 package kube
 
 import (
+	"slices"
 	"strings"
 	"testing"
 
@@ -37,6 +38,774 @@ func TestKubeGenerator(t *testing.T) {
 	}
 }
 
+func TestKubeGeneratorProject(t *testing.T) {
+	compose := &types.ComposeFile{
+		Name: "shop",
+		Services: map[string]types.Service{
+			"web": {
+				Image:   "nginx:latest",
+				Volumes: []string{"static:/usr/share/nginx/html"},
+			},
+		},
+		Volumes: map[string]types.Volume{"static": {}},
+	}
+
+	yaml, err := NewGenerator(compose, "").Generate()
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	for _, expected := range []string{
+		"  name: shop\n",
+		"    com.docker.compose.project: \"shop\"\n",
+		"    io.podman.compose.project: \"shop\"\n",
+		"      claimName: shop_static\n",
+	} {
+		if !strings.Contains(yaml, expected) {
+			t.Errorf("Expected %q in generated YAML:\n%s", expected, yaml)
+		}
+	}
+}
+
+func TestKubeGeneratorHostAliases(t *testing.T) {
+	compose := &types.ComposeFile{
+		Services: map[string]types.Service{
+			"api": {
+				Image: "node:18",
+				Networks: map[string]interface{}{
+					"backend": map[string]interface{}{
+						"aliases":      []interface{}{"api-internal"},
+						"ipv4_address": "10.0.0.5",
+					},
+				},
+			},
+			"db": {Image: "postgres:15"},
+		},
+	}
+
+	gen := NewGenerator(compose, "test-pod")
+	yaml, err := gen.Generate()
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	expected := "  hostAliases:\n  - ip: 127.0.0.1\n    hostnames:\n    - api\n    - api-internal\n    - db\n"
+	if !strings.Contains(yaml, expected) {
+		t.Errorf("Expected host aliases for services, got:\n%s", yaml)
+	}
+	if len(gen.Warnings()) != 1 {
+		t.Errorf("Expected a warning for the static IP, got %v", gen.Warnings())
+	}
+}
+
+func TestKubeGeneratorNetworkMode(t *testing.T) {
+	tests := []struct {
+		name        string
+		services    map[string]types.Service
+		hostNetwork bool
+		wantErr     bool
+	}{
+		{
+			name: "host for all services",
+			services: map[string]types.Service{
+				"vpn": {Image: "wireguard", NetworkMode: "host"},
+				"app": {Image: "app", NetworkMode: "service:vpn"},
+			},
+			hostNetwork: true,
+		},
+		{
+			name: "service sharing in the pod network",
+			services: map[string]types.Service{
+				"web":   {Image: "nginx"},
+				"proxy": {Image: "envoy", NetworkMode: "service:web"},
+			},
+		},
+		{
+			name: "host mixed with pod network",
+			services: map[string]types.Service{
+				"vpn": {Image: "wireguard", NetworkMode: "host"},
+				"web": {Image: "nginx"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "external container",
+			services: map[string]types.Service{
+				"web": {Image: "nginx", NetworkMode: "container:other"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "undefined service",
+			services: map[string]types.Service{
+				"web": {Image: "nginx", NetworkMode: "service:missing"},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compose := &types.ComposeFile{Services: tt.services}
+			yaml, err := NewGenerator(compose, "test-pod").Generate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Generate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if strings.Contains(yaml, "hostNetwork: true") != tt.hostNetwork {
+				t.Errorf("hostNetwork = %v, want %v:\n%s", !tt.hostNetwork, tt.hostNetwork, yaml)
+			}
+		})
+	}
+}
+
+func TestKubeGeneratorVolumeClaims(t *testing.T) {
+	compose := &types.ComposeFile{
+		Services: map[string]types.Service{
+			"web": {
+				Image:   "nginx",
+				Volumes: []string{"nfs-data:/data", "shared:/shared"},
+			},
+		},
+		Volumes: map[string]types.Volume{
+			"nfs-data": {
+				Driver: "local",
+				DriverOpts: map[string]string{
+					"type":   "nfs",
+					"o":      "addr=10.0.0.1,rw",
+					"device": ":/export",
+				},
+			},
+			"shared": {External: true, Name: "team-share"},
+		},
+	}
+
+	yaml, err := NewGenerator(compose, "test-pod").Generate()
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	for _, expected := range []string{
+		"kind: PersistentVolumeClaim\nmetadata:\n  name: nfs-data\n",
+		`    volume.podman.io/driver: "local"`,
+		`    volume.podman.io/type: "nfs"`,
+		`    volume.podman.io/device: ":/export"`,
+		`    volume.podman.io/mount-options: "addr=10.0.0.1,rw"`,
+		"---\napiVersion: v1\nkind: Pod\n",
+		"      claimName: team-share\n",
+	} {
+		if !strings.Contains(yaml, expected) {
+			t.Errorf("Expected %q in generated YAML:\n%s", expected, yaml)
+		}
+	}
+	if strings.Count(yaml, "kind: PersistentVolumeClaim") != 1 {
+		t.Error("External volumes should not get a claim")
+	}
+}
+
+func TestKubeGeneratorEnvFileReference(t *testing.T) {
+	compose := &types.ComposeFile{
+		Services: map[string]types.Service{
+			"web": {
+				Image:       "nginx",
+				Environment: map[string]interface{}{"MODE": "prod"},
+				EnvFileVars: map[string]string{"FROM_FILE": "1"},
+			},
+		},
+	}
+
+	yaml, err := NewGeneratorWithOptions(compose, "shop", Options{EnvFileMode: types.EnvFileReference}).Generate()
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	for _, expected := range []string{
+		"kind: ConfigMap\nmetadata:\n  name: shop-web-env\n",
+		"data:\n  FROM_FILE: \"1\"\n",
+		"    envFrom:\n    - configMapRef:\n        name: shop-web-env\n",
+		"    - name: MODE\n      value: \"prod\"\n",
+	} {
+		if !strings.Contains(yaml, expected) {
+			t.Errorf("Expected %q in generated YAML:\n%s", expected, yaml)
+		}
+	}
+	if strings.Contains(yaml, "- name: FROM_FILE") {
+		t.Error("env_file variables should not be inlined in reference mode")
+	}
+}
+
+func TestKubeGeneratorLabels(t *testing.T) {
+	compose := &types.ComposeFile{
+		Services: map[string]types.Service{
+			"api": {
+				Image:  "node",
+				Labels: map[string]interface{}{"tier": "backend"},
+			},
+			"web": {
+				Image:       "nginx",
+				Labels:      []interface{}{"tier=frontend", "com.example.description=Web frontend", "traefik.http.routers.web.rule=Host(`example.com`)"},
+				Annotations: map[string]interface{}{"io.podman.annotations.label": "disable"},
+			},
+		},
+	}
+
+	gen := NewGenerator(compose, "shop")
+	yaml, err := gen.Generate()
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	for _, expected := range []string{
+		"  labels:\n    app: \"compose2podman\"\n    tier: \"backend\"\n",
+		"    com.example.description: \"Web frontend\"\n",
+		"    io.podman.annotations.label: \"disable\"\n",
+		"    traefik.http.routers.web.rule: \"Host(`example.com`)\"\n",
+	} {
+		if !strings.Contains(yaml, expected) {
+			t.Errorf("Expected %q in generated YAML:\n%s", expected, yaml)
+		}
+	}
+	if len(gen.Warnings()) != 3 {
+		t.Errorf("Expected a conflict and two annotation warnings, got %v", gen.Warnings())
+	}
+}
+
+func TestKubeGeneratorSecurity(t *testing.T) {
+	compose := &types.ComposeFile{
+		Services: map[string]types.Service{
+			"web": {
+				Image:       "nginx",
+				User:        "1000",
+				ReadOnly:    true,
+				UsernsMode:  "auto",
+				GroupAdd:    []string{"2000", "video"},
+				SecurityOpt: []string{"no-new-privileges", "seccomp:unconfined", "label:level:s0:c1", "apparmor:custom"},
+			},
+		},
+	}
+
+	gen := NewGenerator(compose, "shop")
+	yaml, err := gen.Generate()
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	for _, expected := range []string{
+		"    container.apparmor.security.beta.kubernetes.io/web: \"localhost/custom\"\n",
+		"    container.seccomp.security.alpha.kubernetes.io/web: \"unconfined\"\n",
+		"    io.podman.annotations.userns: \"auto\"\n",
+		"  securityContext:\n    supplementalGroups:\n    - 2000\n",
+		"    securityContext:\n      runAsUser: 1000\n      readOnlyRootFilesystem: true\n      allowPrivilegeEscalation: false\n" +
+			"      seccompProfile:\n        type: Unconfined\n      seLinuxOptions:\n        level: \"s0:c1\"\n",
+	} {
+		if !strings.Contains(yaml, expected) {
+			t.Errorf("Expected %q in generated YAML:\n%s", expected, yaml)
+		}
+	}
+	if len(gen.Warnings()) != 1 {
+		t.Errorf("Expected a warning for the non-numeric group, got %v", gen.Warnings())
+	}
+}
+
+func TestKubeGeneratorSeccompProfile(t *testing.T) {
+	compose := &types.ComposeFile{
+		WorkingDir: "/srv/shop",
+		Services: map[string]types.Service{
+			"web": {Image: "nginx", SecurityOpt: []string{"seccomp=profiles/strict.json"}},
+		},
+	}
+
+	gen := NewGenerator(compose, "shop")
+	yaml, err := gen.Generate()
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	for _, expected := range []string{
+		"    container.seccomp.security.alpha.kubernetes.io/web: \"localhost/strict.json\"\n",
+		"      seccompProfile:\n        type: Localhost\n        localhostProfile: \"strict.json\"\n",
+	} {
+		if !strings.Contains(yaml, expected) {
+			t.Errorf("Expected %q in generated YAML:\n%s", expected, yaml)
+		}
+	}
+	want := []string{"service web: podman kube play reads seccomp profiles from its --seccomp-profile-root; copy /srv/shop/profiles/strict.json there as strict.json"}
+	if !slices.Equal(gen.Warnings(), want) {
+		t.Errorf("Expected a warning for the profile root, got %v", gen.Warnings())
+	}
+}
+
+func TestKubeGeneratorCapabilities(t *testing.T) {
+	compose := &types.ComposeFile{
+		Services: map[string]types.Service{
+			"web": {
+				Image:      "nginx",
+				Privileged: true,
+				CapAdd:     []string{"CAP_NET_ADMIN", "sys_time"},
+				CapDrop:    []string{"all"},
+			},
+		},
+	}
+
+	yaml, err := NewGenerator(compose, "shop").Generate()
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	expected := "    securityContext:\n      privileged: true\n      capabilities:\n" +
+		"        add:\n        - NET_ADMIN\n        - SYS_TIME\n        drop:\n        - ALL\n"
+	if !strings.Contains(yaml, expected) {
+		t.Errorf("Expected %q in generated YAML:\n%s", expected, yaml)
+	}
+}
+
+func TestKubeGeneratorDevices(t *testing.T) {
+	compose := &types.ComposeFile{
+		Services: map[string]types.Service{
+			"web": {
+				Image:             "nginx",
+				Devices:           []interface{}{"/dev/null:/dev/target:r", "/dev/dri/renderD128", "/dev/nvme0n1p2", "/dev/dri", "vendor.com/device=gpu0"},
+				DeviceCgroupRules: []string{"c 188:* rmw"},
+			},
+		},
+	}
+
+	gen := NewGenerator(compose, "shop")
+	yaml, err := gen.Generate()
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	for _, expected := range []string{
+		"    - name: dev-null\n      mountPath: /dev/target\n",
+		"  - name: dev-null\n    hostPath:\n      path: /dev/null\n      type: CharDevice\n",
+		"    hostPath:\n      path: /dev/dri/renderD128\n      type: CharDevice\n",
+		"    hostPath:\n      path: /dev/nvme0n1p2\n      type: BlockDevice\n",
+		"    hostPath:\n      path: /dev/dri\n  - name:",
+	} {
+		if !strings.Contains(yaml, expected) {
+			t.Errorf("Expected %q in generated YAML:\n%s", expected, yaml)
+		}
+	}
+	if len(gen.Warnings()) != 4 {
+		t.Errorf("Expected warnings for permissions, the device directory, CDI and cgroup rules, got %v", gen.Warnings())
+	}
+}
+
+func TestKubeGeneratorResources(t *testing.T) {
+	compose := &types.ComposeFile{
+		Services: map[string]types.Service{
+			"api": {
+				Image:   "node",
+				Ulimits: map[string]interface{}{"nofile": 4096},
+				Sysctls: map[string]interface{}{"net.core.somaxconn": 512},
+			},
+			"web": {
+				Image:   "nginx",
+				Tmpfs:   "/run:size=1m,noexec",
+				ShmSize: "64m",
+				Ulimits: map[string]interface{}{"nofile": 1024, "nproc": 100},
+				Sysctls: []interface{}{"net.core.somaxconn=1024", "net.ipv4.tcp_syncookies=1"},
+			},
+		},
+	}
+
+	gen := NewGenerator(compose, "shop")
+	yaml, err := gen.Generate()
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	for _, expected := range []string{
+		"    io.podman.annotations.ulimit: \"nofile=4096,nproc=100\"\n",
+		"    sysctls:\n    - name: net.core.somaxconn\n      value: \"512\"\n    - name: net.ipv4.tcp_syncookies\n      value: \"1\"\n",
+		"    - name: web-tmpfs-run\n      mountPath: /run\n    - name: web-shm\n      mountPath: /dev/shm\n",
+		"  - name: web-shm\n    emptyDir:\n      medium: Memory\n      sizeLimit: 67108864\n",
+		"  - name: web-tmpfs-run\n    emptyDir:\n      medium: Memory\n      sizeLimit: 1048576\n",
+	} {
+		if !strings.Contains(yaml, expected) {
+			t.Errorf("Expected %q in generated YAML:\n%s", expected, yaml)
+		}
+	}
+	if strings.Contains(yaml, "kind: PersistentVolumeClaim") {
+		t.Error("Memory volumes should not get claims")
+	}
+	if len(gen.Warnings()) != 3 {
+		t.Errorf("Expected warnings for noexec, the ulimit and the sysctl conflict, got %v", gen.Warnings())
+	}
+}
+
+func TestKubeGeneratorDNS(t *testing.T) {
+	compose := &types.ComposeFile{
+		Services: map[string]types.Service{
+			"api": {
+				Image:      "node",
+				DNS:        []interface{}{"10.0.0.53", "10.0.0.54"},
+				ExtraHosts: map[string]interface{}{"db": "10.0.0.5", "cache": "10.0.0.5"},
+			},
+			"web": {
+				Image:      "nginx",
+				DNS:        "10.0.0.53",
+				DNSSearch:  "corp.example.com",
+				DNSOpt:     []string{"ndots:2", "use-vc"},
+				ExtraHosts: []interface{}{"gw:host-gateway", "legacy:10.0.0.9"},
+			},
+		},
+	}
+
+	gen := NewGenerator(compose, "shop")
+	yaml, err := gen.Generate()
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	for _, expected := range []string{
+		"  - ip: \"10.0.0.5\"\n    hostnames:\n    - cache\n    - db\n  - ip: \"10.0.0.9\"\n    hostnames:\n    - legacy\n",
+		"  dnsConfig:\n    nameservers:\n    - \"10.0.0.53\"\n    - \"10.0.0.54\"\n    searches:\n    - corp.example.com\n" +
+			"    options:\n    - name: ndots\n      value: \"2\"\n    - name: use-vc\n",
+	} {
+		if !strings.Contains(yaml, expected) {
+			t.Errorf("Expected %q in generated YAML:\n%s", expected, yaml)
+		}
+	}
+	if len(gen.Warnings()) != 1 {
+		t.Errorf("Expected a warning for host-gateway, got %v", gen.Warnings())
+	}
+}
+
+func TestKubeGeneratorRestartPolicy(t *testing.T) {
+	tests := []struct {
+		name     string
+		services map[string]types.Service
+		expected string
+		warnings int
+	}{
+		{
+			name:     "default",
+			services: map[string]types.Service{"web": {Image: "nginx"}},
+			expected: "Never",
+		},
+		{
+			name: "agreeing services",
+			services: map[string]types.Service{
+				"web": {Image: "nginx", Restart: "unless-stopped"},
+				"api": {Image: "node", Restart: "always"},
+			},
+			expected: "Always",
+		},
+		{
+			name: "disagreeing services",
+			services: map[string]types.Service{
+				"web": {Image: "nginx", Restart: "on-failure:3"},
+				"job": {Image: "busybox", Restart: "no"},
+			},
+			expected: "OnFailure",
+			warnings: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen := NewGenerator(&types.ComposeFile{Services: tt.services}, "shop")
+			yaml, err := gen.Generate()
+			if err != nil {
+				t.Fatalf("Generate failed: %v", err)
+			}
+			if !strings.Contains(yaml, "  restartPolicy: "+tt.expected+"\n") {
+				t.Errorf("Expected restartPolicy %s:\n%s", tt.expected, yaml)
+			}
+			if len(gen.Warnings()) != tt.warnings {
+				t.Errorf("Expected %d warnings, got %v", tt.warnings, gen.Warnings())
+			}
+		})
+	}
+}
+
+func TestKubeGeneratorStop(t *testing.T) {
+	runInit := true
+	compose := &types.ComposeFile{
+		Services: map[string]types.Service{
+			"api": {Image: "node", StopGracePeriod: "20s"},
+			"web": {Image: "nginx", StopSignal: "SIGQUIT", StopGracePeriod: "1m30s", Init: &runInit},
+		},
+	}
+
+	gen := NewGenerator(compose, "shop")
+	yaml, err := gen.Generate()
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	for _, expected := range []string{
+		"    io.podman.annotations.init/web: \"true\"\n",
+		"  terminationGracePeriodSeconds: 90\n",
+		"    lifecycle:\n      stopSignal: SIGQUIT\n",
+	} {
+		if !strings.Contains(yaml, expected) {
+			t.Errorf("Expected %q in generated YAML:\n%s", expected, yaml)
+		}
+	}
+	if len(gen.Warnings()) != 2 {
+		t.Errorf("Expected warnings for the differing grace periods and the stop signal, got %v", gen.Warnings())
+	}
+	if !slices.Contains(gen.Warnings(), "service web: stop_signal is written as lifecycle.stopSignal, which older Podman versions ignore") {
+		t.Errorf("Expected a stop_signal warning, got %v", gen.Warnings())
+	}
+}
+
+func TestKubeGeneratorHooks(t *testing.T) {
+	compose := &types.ComposeFile{
+		Services: map[string]types.Service{
+			"web": {
+				Image:      "nginx",
+				StopSignal: "SIGQUIT",
+				PostStart: []types.Hook{
+					{Command: []interface{}{"/app/warmup"}, User: "root"},
+					{Command: "echo second"},
+				},
+				PreStop: []types.Hook{{Command: "nginx -s quit"}},
+			},
+		},
+	}
+
+	gen := NewGenerator(compose, "shop")
+	yaml, err := gen.Generate()
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	expected := "    lifecycle:\n" +
+		"      postStart:\n        exec:\n          command:\n          - \"/app/warmup\"\n" +
+		"      preStop:\n        exec:\n          command:\n          - \"nginx\"\n          - \"-s\"\n          - \"quit\"\n" +
+		"      stopSignal: SIGQUIT\n"
+	if !strings.Contains(yaml, expected) {
+		t.Errorf("Expected %q in generated YAML:\n%s", expected, yaml)
+	}
+	if len(gen.Warnings()) != 3 {
+		t.Errorf("Expected warnings for the second hook, the hook user and the stop signal, got %v", gen.Warnings())
+	}
+}
+
+func TestKubeGeneratorNamespaces(t *testing.T) {
+	tests := []struct {
+		name     string
+		services map[string]types.Service
+		expected []string
+		absent   []string
+		warnings int
+		wantErr  bool
+	}{
+		{
+			name: "shared process namespace",
+			services: map[string]types.Service{
+				"app":   {Image: "app"},
+				"debug": {Image: "busybox", Pid: "service:app", Tty: true, StdinOpen: true},
+			},
+			expected: []string{"  shareProcessNamespace: true\n", "    tty: true\n    stdin: true\n"},
+			absent:   []string{"hostPID"},
+		},
+		{
+			name: "shared process namespace with other services",
+			services: map[string]types.Service{
+				"app":    {Image: "app"},
+				"debug":  {Image: "busybox", Pid: "service:app"},
+				"worker": {Image: "worker"},
+			},
+			expected: []string{"  shareProcessNamespace: true\n"},
+			warnings: 1,
+		},
+		{
+			name: "host namespaces",
+			services: map[string]types.Service{
+				"app":     {Image: "app", Pid: "host", Ipc: "host"},
+				"sidecar": {Image: "busybox", Pid: "service:app"},
+			},
+			expected: []string{"  hostPID: true\n  hostIPC: true\n"},
+			absent:   []string{"shareProcessNamespace"},
+			warnings: 1,
+		},
+		{
+			name:     "container outside the pod",
+			services: map[string]types.Service{"app": {Image: "app", Ipc: "container:other"}},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen := NewGenerator(&types.ComposeFile{Services: tt.services}, "shop")
+			yaml, err := gen.Generate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Generate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			for _, expected := range tt.expected {
+				if !strings.Contains(yaml, expected) {
+					t.Errorf("Expected %q in generated YAML:\n%s", expected, yaml)
+				}
+			}
+			for _, absent := range tt.absent {
+				if strings.Contains(yaml, absent) {
+					t.Errorf("Unexpected %q in generated YAML:\n%s", absent, yaml)
+				}
+			}
+			if !tt.wantErr && len(gen.Warnings()) != tt.warnings {
+				t.Errorf("Expected %d warnings, got %v", tt.warnings, gen.Warnings())
+			}
+		})
+	}
+}
+
+func TestKubeGeneratorLogging(t *testing.T) {
+	compose := &types.ComposeFile{
+		Services: map[string]types.Service{
+			"web": {
+				Image:   "nginx",
+				Logging: &types.Logging{Driver: "json-file", Options: map[string]string{"max-size": "10m", "tag": "web"}},
+			},
+		},
+	}
+
+	gen := NewGenerator(compose, "shop")
+	yaml, err := gen.Generate()
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	for _, expected := range []string{
+		"    log-driver.compose2podman.io/web: \"k8s-file\"\n",
+		"    log-opt.compose2podman.io/web: \"max-size=10m,tag=web\"\n",
+	} {
+		if !strings.Contains(yaml, expected) {
+			t.Errorf("Expected %q in generated YAML:\n%s", expected, yaml)
+		}
+	}
+	if len(gen.Warnings()) != 1 || !strings.Contains(gen.Warnings()[0], "--log-driver k8s-file") {
+		t.Errorf("Expected a warning naming the kube play flags, got %v", gen.Warnings())
+	}
+}
+
+func TestKubeGeneratorImagePolicy(t *testing.T) {
+	compose := &types.ComposeFile{
+		Services: map[string]types.Service{
+			"web": {Image: "docker.io/library/nginx", PullPolicy: "missing", Platform: "linux/arm64"},
+			"api": {Image: "node", PullPolicy: "never", XPodman: &types.XPodman{AutoUpdate: "local"}},
+		},
+	}
+
+	gen := NewGeneratorWithOptions(compose, "shop", Options{AutoUpdate: types.AutoUpdateRegistry})
+	yaml, err := gen.Generate()
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	for _, expected := range []string{
+		"    io.containers.autoupdate/api: \"local\"\n",
+		"    io.containers.autoupdate/web: \"registry\"\n",
+		"    image: node\n    imagePullPolicy: Never\n",
+		"    image: docker.io/library/nginx\n    imagePullPolicy: IfNotPresent\n",
+	} {
+		if !strings.Contains(yaml, expected) {
+			t.Errorf("Expected %q in generated YAML:\n%s", expected, yaml)
+		}
+	}
+	if len(gen.Warnings()) != 1 || !strings.Contains(gen.Warnings()[0], "platform") {
+		t.Errorf("Expected a warning for platform, got %v", gen.Warnings())
+	}
+}
+
+func TestKubeGeneratorXPodman(t *testing.T) {
+	compose := &types.ComposeFile{
+		Services: map[string]types.Service{
+			"web": {Image: "nginx", XPodman: &types.XPodman{UserNS: "keep-id", Notify: "true", PodmanArgs: []string{"--pids-limit=100"}}},
+			"db":  {Image: "postgres", XPodman: &types.XPodman{Notify: "healthy", Quadlet: map[string]map[string]interface{}{"Service": {"Restart": "always"}}}},
+		},
+	}
+
+	gen := NewGenerator(compose, "shop")
+	yaml, err := gen.Generate()
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	for _, expected := range []string{
+		"    io.containers.sdnotify/db: \"healthy\"\n",
+		"    io.containers.sdnotify/web: \"container\"\n",
+		"    io.podman.annotations.userns: \"keep-id\"\n",
+	} {
+		if !strings.Contains(yaml, expected) {
+			t.Errorf("Expected %q in generated YAML:\n%s", expected, yaml)
+		}
+	}
+	expectedWarnings := []string{
+		"service db: x-podman.quadlet.Service has no pod equivalent and was ignored",
+		"service web: x-podman.podman-args has no pod equivalent and was ignored",
+	}
+	if !slices.Equal(gen.Warnings(), expectedWarnings) {
+		t.Errorf("Expected warnings %v, got %v", expectedWarnings, gen.Warnings())
+	}
+
+	// Keys inherited from the top level go to the .kube unit in kube-quadlet mode
+	compose.XPodman = &types.XPodman{Quadlet: map[string]map[string]interface{}{"Service": {"Restart": "always"}}}
+	gen = NewGenerator(compose, "shop")
+	if _, err := gen.Generate(); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	for _, warning := range gen.Warnings() {
+		if strings.Contains(warning, "quadlet.Service") {
+			t.Errorf("Unexpected warning for inherited keys: %s", warning)
+		}
+	}
+}
+
+func TestDeviceHostPathType(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{"/dev/null", "CharDevice"},
+		{"/dev/video0", "CharDevice"},
+		{"/dev/snd/controlC0", "CharDevice"},
+		{"/dev/nvidia0", "CharDevice"},
+		{"/dev/nvme0", "CharDevice"},
+		{"/dev/loop-control", "CharDevice"},
+		{"/dev/sda", "BlockDevice"},
+		{"/dev/sdb1", "BlockDevice"},
+		{"/dev/vda", "BlockDevice"},
+		{"/dev/nvme0n1", "BlockDevice"},
+		{"/dev/loop3", "BlockDevice"},
+		{"/dev/dri", ""},
+		{"/dev/snd/", ""},
+		{"/srv/device", ""},
+	}
+
+	for _, tt := range tests {
+		if result := deviceHostPathType(tt.path); result != tt.expected {
+			t.Errorf("deviceHostPathType(%q) = %q, want %q", tt.path, result, tt.expected)
+		}
+	}
+}
+
+func TestIsLabelKey(t *testing.T) {
+	tests := []struct {
+		key      string
+		expected bool
+	}{
+		{"tier", true},
+		{"app.kubernetes.io/name", true},
+		{"com.example.description", true},
+		{"-tier", false},
+		{"Example.com/name", false},
+		{"/name", false},
+		{"a b", false},
+		{strings.Repeat("x", 64), false},
+	}
+
+	for _, tt := range tests {
+		if result := isLabelKey(tt.key); result != tt.expected {
+			t.Errorf("isLabelKey(%q) = %v, want %v", tt.key, result, tt.expected)
+		}
+	}
+}
+
 func TestParsePort(t *testing.T) {
 	tests := []struct {
 		input         string
@@ -0,0 +1,63 @@
+package kube
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kad/compose2podman/internal/types"
+)
+
+// annotationAutoUpdatePrefix enables podman auto-update for a container;
+// the container name follows the slash
+const annotationAutoUpdatePrefix = "io.containers.autoupdate/"
+
+// imagePullPolicies maps pull policies to imagePullPolicy values
+var imagePullPolicies = map[string]string{
+	types.PullAlways:  "Always",
+	types.PullNever:   "Never",
+	types.PullMissing: "IfNotPresent",
+}
+
+// writeImagePolicy writes the imagePullPolicy of a container from
+// pull_policy and reports platform, which a pod cannot select
+func (g *Generator) writeImagePolicy(sb *strings.Builder, name string, service types.Service) error {
+	pull, err := service.ParsePullPolicy()
+	if err != nil {
+		return fmt.Errorf("service %s: %w", name, err)
+	}
+	switch {
+	case pull.Policy == types.PullBuild:
+		g.warnf("service %s: pull_policy build is not supported (build is not supported); using the default", name)
+	case pull.Refresh:
+		g.warnf("service %s: pull_policy %s has no pod equivalent; using imagePullPolicy Always", name, service.PullPolicy)
+		sb.WriteString("    imagePullPolicy: Always\n")
+	case pull.Policy != "":
+		fmt.Fprintf(sb, "    imagePullPolicy: %s\n", imagePullPolicies[pull.Policy])
+	}
+
+	if service.Platform != "" {
+		g.warnf("service %s: platform %s cannot be set in a pod; pull the image for it beforehand", name, service.Platform)
+	}
+	return nil
+}
+
+// autoUpdateAnnotations returns the auto-update annotations of all
+// containers
+func (g *Generator) autoUpdateAnnotations() (map[string]string, error) {
+	annotations := make(map[string]string)
+	for _, name := range g.serviceNames() {
+		service := g.compose.Services[name]
+		policy, err := service.AutoUpdatePolicy(g.opts.AutoUpdate)
+		if err != nil {
+			return nil, fmt.Errorf("service %s: %w", name, err)
+		}
+		if policy == "" {
+			continue
+		}
+		if policy == types.AutoUpdateRegistry && !types.IsFullyQualifiedImage(service.Image) {
+			g.warnf("service %s: auto-update registry needs a fully qualified image name, not %s", name, service.Image)
+		}
+		annotations[annotationAutoUpdatePrefix+containerName(name, service)] = policy
+	}
+	return annotations, nil
+}
@@ -0,0 +1,86 @@
+package kube
+
+import (
+	"maps"
+	"regexp"
+	"strings"
+)
+
+// labelNamePattern matches the name part of a Kubernetes label key and a
+// non-empty label value
+var labelNamePattern = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9_.-]*[A-Za-z0-9])?$`)
+
+// labelPrefixPattern matches the optional DNS subdomain prefix of a label key
+var labelPrefixPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9.-]*[a-z0-9])?$`)
+
+// isLabelKey reports whether key is a valid Kubernetes label key,
+// [prefix/]name with a DNS subdomain prefix of at most 253 characters and a
+// name of at most 63 characters
+func isLabelKey(key string) bool {
+	prefix, name, found := strings.Cut(key, "/")
+	if !found {
+		prefix, name = "", key
+	} else if prefix == "" || len(prefix) > 253 || !labelPrefixPattern.MatchString(prefix) {
+		return false
+	}
+	return len(name) <= 63 && labelNamePattern.MatchString(name)
+}
+
+// isLabelValue reports whether val is a valid Kubernetes label value
+func isLabelValue(val string) bool {
+	return val == "" || (len(val) <= 63 && labelNamePattern.MatchString(val))
+}
+
+// podMetadata merges the labels and annotations of all services into the
+// pod metadata, as a pod has no per-container labels. Labels that are not
+// valid Kubernetes labels are kept as annotations; when services disagree
+// on a key, the first service in lexical order wins.
+func (g *Generator) podMetadata() (map[string]string, map[string]string, error) {
+	labels := map[string]string{"app": "compose2podman"}
+	for key, val := range g.compose.ProjectLabels() {
+		labels[key] = val
+	}
+	annotations := g.securityAnnotations()
+	maps.Copy(annotations, g.initAnnotations())
+	maps.Copy(annotations, g.logAnnotations())
+	ulimits, err := g.ulimitAnnotations()
+	if err != nil {
+		return nil, nil, err
+	}
+	maps.Copy(annotations, ulimits)
+	autoUpdate, err := g.autoUpdateAnnotations()
+	if err != nil {
+		return nil, nil, err
+	}
+	maps.Copy(annotations, autoUpdate)
+	maps.Copy(annotations, g.xpodmanAnnotations())
+
+	set := func(m map[string]string, kind, service, key, val string) {
+		if old, ok := m[key]; ok {
+			if old != val {
+				g.warnf("service %s: %s %s=%q conflicts with %q already set on the pod; ignored", service, kind, key, val, old)
+			}
+			return
+		}
+		m[key] = val
+	}
+
+	for _, name := range g.serviceNames() {
+		service := g.compose.Services[name]
+		serviceLabels := service.LabelsMap()
+		for _, key := range sortedKeys(serviceLabels) {
+			val := serviceLabels[key]
+			if isLabelKey(key) && isLabelValue(val) {
+				set(labels, "label", name, key, val)
+				continue
+			}
+			g.warnf("service %s: label %s is not a valid Kubernetes label; written as annotation", name, key)
+			set(annotations, "annotation", name, key, val)
+		}
+		serviceAnnotations := service.AnnotationsMap()
+		for _, key := range sortedKeys(serviceAnnotations) {
+			set(annotations, "annotation", name, key, serviceAnnotations[key])
+		}
+	}
+	return labels, annotations, nil
+}
@@ -0,0 +1,119 @@
+package kube
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/kad/compose2podman/internal/types"
+)
+
+// annotationInitPrefix runs a container with an init process in podman
+// kube play; the container name follows the slash
+const annotationInitPrefix = "io.podman.annotations.init/"
+
+// writeLifecycle writes the container lifecycle from post_start, pre_stop
+// and stop_signal. stopSignal is a recent Kubernetes field older podman kube
+// play versions ignore, so it gets a warning.
+func (g *Generator) writeLifecycle(sb *strings.Builder, name string, service types.Service) error {
+	postStart, err := g.lifecycleHandler(name, "post_start", service.PostStart)
+	if err != nil {
+		return err
+	}
+	preStop, err := g.lifecycleHandler(name, "pre_stop", service.PreStop)
+	if err != nil {
+		return err
+	}
+	if postStart == nil && preStop == nil && service.StopSignal == "" {
+		return nil
+	}
+
+	sb.WriteString("    lifecycle:\n")
+	writeExecHandler(sb, "postStart", postStart)
+	writeExecHandler(sb, "preStop", preStop)
+	if service.StopSignal != "" {
+		g.warnf("service %s: stop_signal is written as lifecycle.stopSignal, which older Podman versions ignore", name)
+		fmt.Fprintf(sb, "      stopSignal: %s\n", service.StopSignal)
+	}
+	return nil
+}
+
+// lifecycleHandler returns the command of the first hook. A pod runs one
+// handler per hook with the container's user, directory and environment,
+// so other hooks and those settings are reported as dropped.
+func (g *Generator) lifecycleHandler(name, field string, hooks []types.Hook) ([]string, error) {
+	if len(hooks) == 0 {
+		return nil, nil
+	}
+	if len(hooks) > 1 {
+		g.warnf("service %s: a pod supports one %s hook; only the first was kept", name, field)
+	}
+	hook := hooks[0]
+	if hook.User != "" || hook.Privileged || hook.WorkingDir != "" || hook.Environment != nil {
+		g.warnf("service %s: user, privileged, working_dir and environment of %s cannot be set in a pod and were dropped", name, field)
+	}
+	command, err := hook.CommandList()
+	if err != nil {
+		return nil, fmt.Errorf("service %s: %s %w", name, field, err)
+	}
+	return command, nil
+}
+
+func writeExecHandler(sb *strings.Builder, field string, command []string) {
+	if len(command) == 0 {
+		return
+	}
+	fmt.Fprintf(sb, "      %s:\n", field)
+	sb.WriteString("        exec:\n")
+	sb.WriteString("          command:\n")
+	for _, arg := range command {
+		fmt.Fprintf(sb, "          - %s\n", quote(arg))
+	}
+}
+
+// initAnnotations returns the annotations enabling init for the containers
+// of services with init: true
+func (g *Generator) initAnnotations() map[string]string {
+	annotations := make(map[string]string)
+	for _, name := range g.serviceNames() {
+		service := g.compose.Services[name]
+		if service.Init != nil && *service.Init {
+			annotations[annotationInitPrefix+containerName(name, service)] = "true"
+		}
+	}
+	return annotations
+}
+
+// writeTerminationGracePeriod writes the pod grace period from
+// stop_grace_period. It applies to all containers, so the longest period of
+// all services is used.
+func (g *Generator) writeTerminationGracePeriod(sb *strings.Builder) error {
+	var seconds []int64
+	longest := int64(-1)
+	for _, name := range g.serviceNames() {
+		service := g.compose.Services[name]
+		grace, set, err := service.StopGracePeriodDuration()
+		if err != nil {
+			return fmt.Errorf("service %s: %w", name, err)
+		}
+		if !set {
+			continue
+		}
+		s := int64(math.Ceil(grace.Seconds()))
+		seconds = append(seconds, s)
+		longest = max(longest, s)
+	}
+
+	if longest < 0 {
+		return nil
+	}
+	for _, s := range seconds {
+		if s != longest {
+			g.warnf("services have different stop_grace_period values; the pod uses the longest, %ds, for all containers", longest)
+			break
+		}
+	}
+	sb.WriteString("  terminationGracePeriodSeconds: " + strconv.FormatInt(longest, 10) + "\n")
+	return nil
+}
@@ -0,0 +1,45 @@
+package kube
+
+import (
+	"slices"
+	"strings"
+)
+
+// Annotations recording the Podman log settings of each container; the
+// container name follows the slash. podman kube play takes the log driver
+// only from its --log-driver and --log-opt flags, so they document the
+// settings to pass there.
+const (
+	annotationLogDriverPrefix = "log-driver.compose2podman.io/"
+	annotationLogOptPrefix    = "log-opt.compose2podman.io/"
+)
+
+// logAnnotations returns the log annotations of all containers
+func (g *Generator) logAnnotations() map[string]string {
+	annotations := make(map[string]string)
+	var drivers []string
+	for _, name := range g.serviceNames() {
+		service := g.compose.Services[name]
+		logging, errs := service.PodmanLogging()
+		for _, err := range errs {
+			g.warnf("service %s: %v", name, err)
+		}
+		if logging == nil {
+			continue
+		}
+
+		container := containerName(name, service)
+		annotations[annotationLogDriverPrefix+container] = logging.Driver
+		if len(logging.Options) > 0 {
+			annotations[annotationLogOptPrefix+container] = strings.Join(logging.Options, ",")
+		}
+		if !slices.Contains(drivers, logging.Driver) {
+			drivers = append(drivers, logging.Driver)
+		}
+	}
+
+	if len(drivers) > 0 {
+		g.warnf("logging is not applied by podman kube play; pass --log-driver %s and --log-opt as recorded in the log annotations", strings.Join(drivers, " or "))
+	}
+	return annotations
+}
@@ -0,0 +1,102 @@
+package kube
+
+import (
+	"fmt"
+	"strings"
+)
+
+// podNamespaces holds the pod-level namespace settings derived from pid
+// and ipc
+type podNamespaces struct {
+	hostPID               bool
+	hostIPC               bool
+	shareProcessNamespace bool
+}
+
+// podNamespaceSettings derives the pod PID and IPC namespaces. Containers of
+// a pod always share the IPC namespace and share the PID namespace only all
+// together, so a service joining another's namespace makes every container
+// share it, and host namespaces apply to all containers. Such broadening is
+// reported as warnings; references to containers outside the pod are errors.
+func (g *Generator) podNamespaceSettings() (podNamespaces, error) {
+	var ns podNamespaces
+	var pidHost, pidOwn, ipcHost, ipcOwn []string
+	// pidShared holds the services other services join with pid service:,
+	// which share their PID namespace in Compose too
+	pidShared := make(map[string]bool)
+
+	for _, name := range g.serviceNames() {
+		service := g.compose.Services[name]
+		for _, field := range []struct{ name, mode string }{{"pid", service.Pid}, {"ipc", service.Ipc}} {
+			if strings.HasPrefix(field.mode, "container:") {
+				return ns, fmt.Errorf("service %s: %s %s refers to a container outside the pod", name, field.name, field.mode)
+			}
+			if owner, ok := strings.CutPrefix(field.mode, "service:"); ok {
+				if _, exists := g.compose.Services[owner]; !exists {
+					return ns, fmt.Errorf("service %s: %s refers to undefined service %s", name, field.name, owner)
+				}
+			}
+		}
+
+		switch {
+		case service.Pid == "host":
+			pidHost = append(pidHost, name)
+		case strings.HasPrefix(service.Pid, "service:"):
+			ns.shareProcessNamespace = true
+			pidShared[strings.TrimPrefix(service.Pid, "service:")] = true
+		case service.Pid != "" && service.Pid != "private":
+			g.warnf("service %s: pid %s has no pod equivalent and was ignored", name, service.Pid)
+			fallthrough
+		default:
+			pidOwn = append(pidOwn, name)
+		}
+
+		switch service.Ipc {
+		case "host":
+			ipcHost = append(ipcHost, name)
+		case "private", "none":
+			g.warnf("service %s: ipc %s cannot be set in a pod; containers of a pod share one IPC namespace", name, service.Ipc)
+			ipcOwn = append(ipcOwn, name)
+		default:
+			ipcOwn = append(ipcOwn, name)
+		}
+	}
+
+	if len(pidHost) > 0 {
+		ns.hostPID = true
+		ns.shareProcessNamespace = false
+		if len(pidOwn) > 0 {
+			g.warnf("pid host of %s also applies to %s in the pod", strings.Join(pidHost, ", "), strings.Join(pidOwn, ", "))
+		}
+	} else if ns.shareProcessNamespace {
+		var others []string
+		for _, name := range pidOwn {
+			if !pidShared[name] {
+				others = append(others, name)
+			}
+		}
+		if len(others) > 0 {
+			g.warnf("a shared PID namespace also applies to %s in the pod", strings.Join(others, ", "))
+		}
+	}
+	if len(ipcHost) > 0 {
+		ns.hostIPC = true
+		if len(ipcOwn) > 0 {
+			g.warnf("ipc host of %s also applies to %s in the pod", strings.Join(ipcHost, ", "), strings.Join(ipcOwn, ", "))
+		}
+	}
+	return ns, nil
+}
+
+// write writes the pod spec fields of the namespace settings
+func (ns podNamespaces) write(sb *strings.Builder) {
+	if ns.hostPID {
+		sb.WriteString("  hostPID: true\n")
+	}
+	if ns.hostIPC {
+		sb.WriteString("  hostIPC: true\n")
+	}
+	if ns.shareProcessNamespace {
+		sb.WriteString("  shareProcessNamespace: true\n")
+	}
+}
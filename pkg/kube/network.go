@@ -0,0 +1,76 @@
+package kube
+
+import (
+	"fmt"
+	"strings"
+)
+
+// podNetworkMode returns the network mode shared by all services: "host",
+// "none" or "" for the pod's own network namespace. All containers of a pod
+// share one network namespace, so network_mode: service:<name> is always
+// satisfied, but services asking for different namespaces cannot be placed
+// in the same pod.
+func (g *Generator) podNetworkMode() (string, error) {
+	modes := make(map[string]string)
+	for _, name := range g.serviceNames() {
+		mode, err := g.effectiveNetworkMode(name, nil)
+		if err != nil {
+			return "", err
+		}
+		modes[name] = mode
+	}
+
+	podMode, first := "", ""
+	for i, name := range g.serviceNames() {
+		if i == 0 {
+			podMode, first = modes[name], name
+			continue
+		}
+		if modes[name] != podMode {
+			return "", fmt.Errorf("services %s (%s) and %s (%s) need different network namespaces and cannot share a pod",
+				first, describeNetworkMode(podMode), name, describeNetworkMode(modes[name]))
+		}
+	}
+
+	if podMode == "none" {
+		g.warnf("network_mode none has no pod equivalent; use podman kube play --network none")
+	}
+	return podMode, nil
+}
+
+// effectiveNetworkMode resolves network_mode: service:<name> chains to the
+// mode of the service owning the namespace
+func (g *Generator) effectiveNetworkMode(name string, visited []string) (string, error) {
+	service, exists := g.compose.Services[name]
+	if !exists {
+		return "", fmt.Errorf("service %s: network_mode refers to undefined service %s", visited[len(visited)-1], name)
+	}
+	for _, v := range visited {
+		if v == name {
+			return "", fmt.Errorf("service %s: network_mode forms a cycle: %s -> %s", name, strings.Join(visited, " -> "), name)
+		}
+	}
+
+	mode := service.NetworkMode
+	if owner, ok := service.NetworkModeService(); ok {
+		return g.effectiveNetworkMode(owner, append(visited, name))
+	}
+	switch {
+	case mode == "" || mode == "bridge":
+		return "", nil
+	case mode == "host" || mode == "none":
+		return mode, nil
+	case strings.HasPrefix(mode, "container:"):
+		return "", fmt.Errorf("service %s: network_mode %s refers to a container outside the pod", name, mode)
+	default:
+		g.warnf("service %s: network_mode %s has no pod equivalent and was ignored", name, mode)
+		return "", nil
+	}
+}
+
+func describeNetworkMode(mode string) string {
+	if mode == "" {
+		return "pod network"
+	}
+	return "network_mode " + mode
+}
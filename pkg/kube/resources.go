@@ -0,0 +1,121 @@
+package kube
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/kad/compose2podman/internal/types"
+)
+
+// annotationUlimit sets the ulimits of all containers in podman kube play
+const annotationUlimit = "io.podman.annotations.ulimit"
+
+// memoryMount is a tmpfs of a service, represented as a memory-backed
+// emptyDir volume
+type memoryMount struct {
+	volume    string
+	path      string
+	sizeLimit string
+}
+
+// memoryMounts returns the tmpfs mounts and the /dev/shm mount for
+// shm_size of a service. Volumes are named per service since an emptyDir is
+// shared by all containers mounting it.
+func (g *Generator) memoryMounts(name string, service types.Service) ([]memoryMount, error) {
+	var mounts []memoryMount
+	for _, tmpfs := range service.TmpfsList() {
+		size, err := tmpfs.Size()
+		if err != nil {
+			return nil, fmt.Errorf("service %s: tmpfs %s: %w", name, tmpfs.Path, err)
+		}
+		for _, option := range strings.Split(tmpfs.Options, ",") {
+			if option != "" && !strings.HasPrefix(option, "size=") {
+				g.warnf("service %s: tmpfs option %s on %s cannot be set in a pod and was dropped", name, option, tmpfs.Path)
+			}
+		}
+		mounts = append(mounts, memoryMount{
+			volume:    pathToVolumeName(name + "-tmpfs" + tmpfs.Path),
+			path:      tmpfs.Path,
+			sizeLimit: sizeLimit(size),
+		})
+	}
+
+	if service.ShmSize != "" {
+		size, err := types.ParseByteSize(service.ShmSize)
+		if err != nil {
+			return nil, fmt.Errorf("service %s: shm_size: %w", name, err)
+		}
+		mounts = append(mounts, memoryMount{
+			volume:    pathToVolumeName(name + "-shm"),
+			path:      "/dev/shm",
+			sizeLimit: sizeLimit(size),
+		})
+	}
+	return mounts, nil
+}
+
+// sizeLimit returns an emptyDir sizeLimit in bytes, or "" for no limit
+func sizeLimit(size int64) string {
+	if size == 0 {
+		return ""
+	}
+	return strconv.FormatInt(size, 10)
+}
+
+// ulimitAnnotations returns the annotation carrying the ulimits of all
+// services. Podman applies it to every container of the pod, so limits are
+// merged and the first service in lexical order wins on conflicts.
+func (g *Generator) ulimitAnnotations() (map[string]string, error) {
+	var merged []types.Ulimit
+	owner := make(map[string]string)
+	for _, name := range g.serviceNames() {
+		service := g.compose.Services[name]
+		limits, err := service.UlimitsList()
+		if err != nil {
+			return nil, fmt.Errorf("service %s: %w", name, err)
+		}
+		for _, limit := range limits {
+			i := slices.IndexFunc(merged, func(u types.Ulimit) bool { return u.Name == limit.Name })
+			if i < 0 {
+				merged = append(merged, limit)
+				owner[limit.Name] = name
+			} else if merged[i] != limit {
+				g.warnf("service %s: ulimit %s conflicts with %s from service %s; ignored", name, limit, merged[i], owner[limit.Name])
+			}
+		}
+	}
+
+	if len(merged) == 0 {
+		return nil, nil
+	}
+	values := make([]string, 0, len(merged))
+	for _, limit := range merged {
+		values = append(values, limit.String())
+	}
+	return map[string]string{annotationUlimit: strings.Join(values, ",")}, nil
+}
+
+// podSysctls merges the sysctls of all services, which Kubernetes sets for
+// the whole pod
+func (g *Generator) podSysctls() map[string]string {
+	sysctls := make(map[string]string)
+	owner := make(map[string]string)
+	for _, name := range g.serviceNames() {
+		service := g.compose.Services[name]
+		serviceSysctls := service.SysctlsMap()
+		for _, key := range sortedKeys(serviceSysctls) {
+			val := serviceSysctls[key]
+			if old, ok := sysctls[key]; ok {
+				if old != val {
+					g.warnf("service %s: sysctl %s=%s conflicts with %s from service %s; ignored", name, key, val, old, owner[key])
+				}
+				continue
+			}
+			sysctls[key] = val
+			owner[key] = name
+		}
+	}
+	return sysctls
+}
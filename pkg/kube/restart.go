@@ -0,0 +1,50 @@
+package kube
+
+import (
+	"fmt"
+
+	"github.com/kad/compose2podman/internal/types"
+)
+
+// podRestartPolicies maps Compose restart policies to pod restart policies
+var podRestartPolicies = map[string]string{
+	types.RestartNo:            "Never",
+	types.RestartAlways:        "Always",
+	types.RestartOnFailure:     "OnFailure",
+	types.RestartUnlessStopped: "Always",
+}
+
+// podRestartPolicy derives the restart policy of the pod, which applies to
+// all containers. When services disagree the most restarting policy is used,
+// so no service that Compose would restart stays down.
+func (g *Generator) podRestartPolicy() (string, error) {
+	rank := map[string]int{"Never": 0, "OnFailure": 1, "Always": 2}
+	policy := ""
+	disagree := false
+	for _, name := range g.serviceNames() {
+		service := g.compose.Services[name]
+		restart, err := service.RestartSettings()
+		if err != nil {
+			return "", fmt.Errorf("service %s: %w", name, err)
+		}
+		if restart.MaxAttempts > 0 || restart.Delay > 0 || restart.Window > 0 {
+			g.warnf("service %s: restart retry limits and delays cannot be set in a pod and were dropped", name)
+		}
+
+		servicePolicy := podRestartPolicies[restart.Policy]
+		if policy != "" && servicePolicy != policy {
+			disagree = true
+		}
+		if policy == "" || rank[servicePolicy] > rank[policy] {
+			policy = servicePolicy
+		}
+	}
+
+	if policy == "" {
+		policy = "Never"
+	}
+	if disagree {
+		g.warnf("services have different restart policies; the pod uses restartPolicy %s for all containers", policy)
+	}
+	return policy, nil
+}
@@ -0,0 +1,204 @@
+package kube
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/kad/compose2podman/internal/types"
+)
+
+// Annotations understood by podman kube play for settings that have no
+// pod spec field
+const (
+	annotationUserNS         = "io.podman.annotations.userns"
+	annotationAppArmorPrefix = "container.apparmor.security.beta.kubernetes.io/"
+	annotationSeccompPrefix  = "container.seccomp.security.alpha.kubernetes.io/"
+)
+
+// containerName returns the name of the container of a service in the pod
+func containerName(name string, service types.Service) string {
+	if service.ContainerName != "" {
+		return service.ContainerName
+	}
+	return name
+}
+
+// writeSecurityContext writes the container securityContext from user,
+// privileged, cap_add/cap_drop, read_only and security_opt
+func (g *Generator) writeSecurityContext(sb *strings.Builder, name string, service types.Service) {
+	opts := service.SecurityOptions()
+
+	var lines []string
+	if service.User != "" {
+		// Parse user:group format
+		uid, gid := parseUser(service.User)
+		if uid != "" {
+			lines = append(lines, "      runAsUser: "+uid)
+		}
+		if gid != "" {
+			lines = append(lines, "      runAsGroup: "+gid)
+		}
+	}
+	if service.Privileged {
+		lines = append(lines, "      privileged: true")
+	}
+	if add, drop := service.Capabilities(); len(add) > 0 || len(drop) > 0 {
+		lines = append(lines, "      capabilities:")
+		lines = append(lines, capabilityList("add", add)...)
+		lines = append(lines, capabilityList("drop", drop)...)
+	}
+	if service.ReadOnly {
+		lines = append(lines, "      readOnlyRootFilesystem: true")
+	}
+	if opts.NoNewPrivileges {
+		lines = append(lines, "      allowPrivilegeEscalation: false")
+	}
+
+	switch opts.Seccomp {
+	case "":
+	case "unconfined":
+		lines = append(lines, "      seccompProfile:", "        type: Unconfined")
+	default:
+		profile := seccompProfileName(opts.Seccomp)
+		g.warnf("service %s: podman kube play reads seccomp profiles from its --seccomp-profile-root; copy %s there as %s", name, g.compose.ResolvePath(opts.Seccomp), profile)
+		lines = append(lines, "      seccompProfile:", "        type: Localhost",
+			"        localhostProfile: "+quote(profile))
+	}
+
+	// Podman maps the spc_t type to disabled labeling, as for privileged
+	labelType := opts.LabelType
+	if opts.LabelDisable && labelType == "" {
+		labelType = "spc_t"
+	}
+	var selinux []string
+	for _, field := range []struct{ key, val string }{
+		{"level", opts.LabelLevel}, {"role", opts.LabelRole}, {"type", labelType}, {"user", opts.LabelUser},
+	} {
+		if field.val != "" {
+			selinux = append(selinux, fmt.Sprintf("        %s: %s", field.key, quote(field.val)))
+		}
+	}
+	if len(selinux) > 0 {
+		lines = append(lines, "      seLinuxOptions:")
+		lines = append(lines, selinux...)
+	}
+
+	if opts.LabelNested {
+		g.warnf("service %s: security_opt label=nested is not supported in a pod and was dropped", name)
+	}
+	for _, path := range append(opts.Mask, opts.Unmask...) {
+		g.warnf("service %s: security_opt mask/unmask %s is not supported in a pod and was dropped", name, path)
+	}
+	for _, opt := range opts.Other {
+		g.warnf("service %s: security_opt %s is not supported in a pod and was dropped", name, opt)
+	}
+
+	if len(lines) == 0 {
+		return
+	}
+	sb.WriteString("    securityContext:\n")
+	sb.WriteString(strings.Join(lines, "\n") + "\n")
+}
+
+// capabilityList returns the lines of a capabilities add or drop list.
+// Kubernetes names capabilities without the CAP_ prefix.
+func capabilityList(field string, caps []string) []string {
+	if len(caps) == 0 {
+		return nil
+	}
+	lines := []string{"        " + field + ":"}
+	for _, cap := range caps {
+		lines = append(lines, "        - "+strings.TrimPrefix(cap, "CAP_"))
+	}
+	return lines
+}
+
+// seccompProfileName returns the name of a seccomp profile relative to the
+// profile root, where Kubernetes and podman kube play look up Localhost
+// profiles: the file name of the profile path
+func seccompProfileName(path string) string {
+	return filepath.Base(path)
+}
+
+// securityAnnotations returns the pod annotations carrying the AppArmor and
+// seccomp profiles of each container and the user namespace mode. podman
+// kube play reads the seccomp profile from the annotation, not from the
+// securityContext.
+func (g *Generator) securityAnnotations() map[string]string {
+	annotations := make(map[string]string)
+	userns := ""
+	for _, name := range g.serviceNames() {
+		service := g.compose.Services[name]
+		if profile := service.SecurityOptions().AppArmor; profile != "" {
+			if profile != "unconfined" {
+				profile = "localhost/" + profile
+			}
+			annotations[annotationAppArmorPrefix+containerName(name, service)] = profile
+		}
+		if profile := service.SecurityOptions().Seccomp; profile != "" {
+			if profile != "unconfined" {
+				profile = "localhost/" + seccompProfileName(profile)
+			}
+			annotations[annotationSeccompPrefix+containerName(name, service)] = profile
+		}
+		mode := service.UserNSMode()
+		if mode == "" {
+			continue
+		}
+		if userns != "" && userns != mode {
+			g.warnf("service %s: userns_mode %s conflicts with %s; a pod has a single user namespace", name, mode, userns)
+			continue
+		}
+		userns = mode
+	}
+	if userns != "" {
+		annotations[annotationUserNS] = userns
+	}
+	return annotations
+}
+
+// writePodSecurityContext writes the pod securityContext. group_add and
+// sysctls apply to all containers of a pod, so those of all services are
+// merged.
+func (g *Generator) writePodSecurityContext(sb *strings.Builder) {
+	var groups []int64
+	for _, name := range g.serviceNames() {
+		service := g.compose.Services[name]
+		for _, group := range service.GroupAdd {
+			gid, err := strconv.ParseInt(group, 10, 64)
+			if err != nil {
+				g.warnf("service %s: group_add %s is not numeric; supplementalGroups need a GID and it was dropped", name, group)
+				continue
+			}
+			if !slices.Contains(groups, gid) {
+				groups = append(groups, gid)
+			}
+		}
+		if len(service.GroupAdd) > 0 && len(g.compose.Services) > 1 {
+			g.warnf("service %s: group_add applies to every container of the pod", name)
+		}
+	}
+
+	sysctls := g.podSysctls()
+	if len(groups) == 0 && len(sysctls) == 0 {
+		return
+	}
+	sb.WriteString("  securityContext:\n")
+	if len(groups) > 0 {
+		slices.Sort(groups)
+		sb.WriteString("    supplementalGroups:\n")
+		for _, gid := range groups {
+			fmt.Fprintf(sb, "    - %d\n", gid)
+		}
+	}
+	if len(sysctls) > 0 {
+		sb.WriteString("    sysctls:\n")
+		for _, key := range sortedKeys(sysctls) {
+			fmt.Fprintf(sb, "    - name: %s\n", key)
+			fmt.Fprintf(sb, "      value: %s\n", quote(sysctls[key]))
+		}
+	}
+}
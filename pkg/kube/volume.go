@@ -0,0 +1,98 @@
+package kube
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// podmanVolumeAnnotations maps local driver options to the PVC annotations
+// podman kube play uses when creating the volume
+var podmanVolumeAnnotations = map[string]string{
+	"type":   "volume.podman.io/type",
+	"device": "volume.podman.io/device",
+	"o":      "volume.podman.io/mount-options",
+	"uid":    "volume.podman.io/uid",
+	"gid":    "volume.podman.io/gid",
+}
+
+// generateClaims returns a PersistentVolumeClaim document for every named
+// volume of the pod that is declared in the compose file. Podman creates
+// the volume from the claim, including its driver and options. External
+// volumes are expected to exist and get no claim.
+func (g *Generator) generateClaims(usedVolumes map[string]*volumeInfo) string {
+	var sb strings.Builder
+
+	for _, volName := range sortedKeys(usedVolumes) {
+		if usedVolumes[volName].isPath || usedVolumes[volName].memory {
+			continue
+		}
+		volume, declared := g.compose.Volumes[volName]
+		if !declared || volume.External {
+			continue
+		}
+
+		annotations := make(map[string]string)
+		if volume.Driver != "" {
+			annotations["volume.podman.io/driver"] = volume.Driver
+		}
+		for _, key := range sortedKeys(volume.DriverOpts) {
+			annotation, ok := podmanVolumeAnnotations[key]
+			if !ok {
+				g.warnf("volume %s: driver option %s cannot be set through a claim and was dropped", volName, key)
+				continue
+			}
+			annotations[annotation] = volume.DriverOpts[key]
+		}
+
+		labels := make(map[string]string)
+		for key, val := range volume.Labels {
+			labels[key] = val
+		}
+		for key, val := range g.compose.ProjectLabels() {
+			labels[key] = val
+		}
+
+		sb.WriteString("apiVersion: v1\n")
+		sb.WriteString("kind: PersistentVolumeClaim\n")
+		sb.WriteString("metadata:\n")
+		fmt.Fprintf(&sb, "  name: %s\n", g.claimName(volName))
+		writeStringMap(&sb, "  ", "labels", labels)
+		writeStringMap(&sb, "  ", "annotations", annotations)
+		sb.WriteString("spec:\n")
+		sb.WriteString("  accessModes:\n")
+		sb.WriteString("  - ReadWriteOnce\n")
+		sb.WriteString("  resources:\n")
+		sb.WriteString("    requests:\n")
+		sb.WriteString("      storage: 1Gi\n")
+		sb.WriteString("---\n")
+	}
+
+	return sb.String()
+}
+
+// writeStringMap writes a YAML mapping with quoted values, sorted by key.
+// Nothing is written for an empty map.
+func writeStringMap(sb *strings.Builder, indent, field string, m map[string]string) {
+	if len(m) == 0 {
+		return
+	}
+	fmt.Fprintf(sb, "%s%s:\n", indent, field)
+	for _, key := range sortedKeys(m) {
+		fmt.Fprintf(sb, "%s  %s: %s\n", indent, yamlKey(key), quote(m[key]))
+	}
+}
+
+// yamlKey returns key as a mapping key, quoted unless it is a valid
+// Kubernetes label key and therefore safe as a plain scalar
+func yamlKey(key string) string {
+	if isLabelKey(key) {
+		return key
+	}
+	return quote(key)
+}
+
+// quote returns s as a double-quoted YAML scalar
+func quote(s string) string {
+	return strconv.Quote(s)
+}
@@ -0,0 +1,72 @@
+package kube
+
+import (
+	"reflect"
+
+	"github.com/kad/compose2podman/internal/types"
+)
+
+// annotationSDNotifyPrefix is followed by the container name; podman kube
+// play reads the sd_notify policy of each container from it
+const annotationSDNotifyPrefix = "io.containers.sdnotify/"
+
+// sdNotifyPolicies maps x-podman.notify to podman sd_notify policies
+var sdNotifyPolicies = map[string]string{
+	types.NotifyTrue:    "container",
+	types.NotifyHealthy: "healthy",
+}
+
+// xpodmanAnnotations returns the sd_notify annotations of all containers.
+// Other x-podman settings configure podman run or the Quadlet unit and
+// have no pod equivalent, so they are reported as warnings.
+func (g *Generator) xpodmanAnnotations() map[string]string {
+	annotations := make(map[string]string)
+	for _, name := range g.serviceNames() {
+		service := g.compose.Services[name]
+		x := service.XPodman
+		if x == nil {
+			continue
+		}
+		if policy, ok := sdNotifyPolicies[x.Notify]; ok {
+			annotations[annotationSDNotifyPrefix+containerName(name, service)] = policy
+		}
+
+		for _, setting := range []struct {
+			key string
+			set bool
+		}{
+			{"pod", x.Pod != ""},
+			{"podman-args", len(x.PodmanArgs) > 0},
+			{"global-args", len(x.GlobalArgs) > 0},
+			{"relabel", x.Relabel != ""},
+			{"port-handler", x.PortHandler != ""},
+		} {
+			if setting.set {
+				g.warnf("service %s: x-podman.%s has no pod equivalent and was ignored", name, setting.key)
+			}
+		}
+		for _, section := range g.ignoredQuadletSections(x) {
+			g.warnf("service %s: x-podman.quadlet.%s has no pod equivalent and was ignored", name, section)
+		}
+	}
+	return annotations
+}
+
+// ignoredQuadletSections returns the raw Quadlet sections of a service that
+// no generated unit receives. Container keys never apply to a pod; the
+// other sections inherited unchanged from the top-level block go to the
+// .kube unit when the pod runs under Quadlet, so only service-level ones
+// are reported.
+func (g *Generator) ignoredQuadletSections(x *types.XPodman) []string {
+	var top map[string]map[string]interface{}
+	if g.compose.XPodman != nil {
+		top = g.compose.XPodman.Quadlet
+	}
+	var sections []string
+	for _, section := range sortedKeys(x.Quadlet) {
+		if section == "Container" || !reflect.DeepEqual(x.Quadlet[section], top[section]) {
+			sections = append(sections, section)
+		}
+	}
+	return sections
+}
@@ -0,0 +1,257 @@
+package parser
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kad/compose2podman/internal/types"
+)
+
+// loadEnvFiles reads the env_file entries of every service, resolving paths
+// relative to the compose file directory. Missing optional files are
+// skipped; missing required files are an error, as in Compose.
+func loadEnvFiles(compose *types.ComposeFile) error {
+	for name, service := range compose.Services {
+		files := service.EnvFiles()
+		if len(files) == 0 {
+			continue
+		}
+
+		service.EnvFileVars = make(map[string]string)
+		service.EnvFileInline = make(map[string]string)
+		for _, file := range files {
+			path := file.Path
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(compose.WorkingDir, path)
+			}
+
+			vars, podmanVars, err := readEnvFile(path, file.Format)
+			if errors.Is(err, fs.ErrNotExist) && !file.Required {
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("service %s: env_file %s: %w", name, file.Path, err)
+			}
+
+			for key, val := range vars {
+				service.EnvFileVars[key] = val
+				if podmanVal, ok := podmanVars[key]; ok && podmanVal == val {
+					delete(service.EnvFileInline, key)
+				} else {
+					service.EnvFileInline[key] = val
+				}
+			}
+			if podmanVars != nil {
+				service.EnvFilePaths = append(service.EnvFilePaths, path)
+			}
+		}
+		compose.Services[name] = service
+	}
+	return nil
+}
+
+// readEnvFile returns the variables of an env file as Compose reads them,
+// and as Podman --env-file reads them: verbatim, like the raw format. The
+// Podman variables are nil when Podman cannot read the file.
+// nolint:gosec // G304: env_file paths come from the user's compose file
+func readEnvFile(path, format string) (vars, podmanVars map[string]string, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	vars, err = ParseEnvFile(bytes.NewReader(data), format, os.LookupEnv)
+	if err != nil {
+		return nil, nil, err
+	}
+	podmanVars, _ = ParseEnvFile(bytes.NewReader(data), "raw", os.LookupEnv)
+	return vars, podmanVars, nil
+}
+
+// ParseEnvFile parses an env file with Compose semantics:
+//   - blank lines and lines starting with # are ignored
+//   - an optional "export " prefix is accepted
+//   - single-quoted values are literal, double-quoted values support
+//     escapes and interpolation; both may span multiple lines
+//   - unquoted values are trimmed and end at an inline " #" comment
+//   - ${VAR}, ${VAR:-default}, ${VAR-default} and $VAR are interpolated
+//     from earlier variables of the file and then from lookup
+//   - a key without "=" takes its value from lookup and is skipped if unset
+//
+// With format "raw" values are taken verbatim, without quote handling or
+// interpolation.
+func ParseEnvFile(r io.Reader, format string, lookup func(string) (string, bool)) (map[string]string, error) {
+	if format != "" && format != "raw" {
+		return nil, fmt.Errorf("unsupported env_file format %q", format)
+	}
+	raw := format == "raw"
+
+	vars := make(map[string]string)
+	resolve := func(key string) (string, bool) {
+		if val, ok := vars[key]; ok {
+			return val, true
+		}
+		return lookup(key)
+	}
+
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimLeft(scanner.Text(), " \t")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !raw {
+			line = strings.TrimPrefix(line, "export ")
+		}
+
+		key, value, hasValue := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !isValidEnvKey(key) {
+			return nil, fmt.Errorf("line %d: invalid variable name %q", lineNo, key)
+		}
+		if !hasValue {
+			if val, ok := lookup(key); ok {
+				vars[key] = val
+			}
+			continue
+		}
+		if raw {
+			vars[key] = value
+			continue
+		}
+
+		value = strings.TrimLeft(value, " \t")
+		if value != "" && (value[0] == '"' || value[0] == '\'') {
+			quote, rest := value[0], value[1:]
+			body, closed := closingQuote(rest, quote)
+			for !closed && scanner.Scan() {
+				lineNo++
+				rest += "\n" + scanner.Text()
+				body, closed = closingQuote(rest, quote)
+			}
+			if !closed {
+				return nil, fmt.Errorf("line %d: unterminated quoted value for %s", lineNo, key)
+			}
+			if quote == '\'' {
+				vars[key] = body
+			} else {
+				vars[key] = interpolate(unescape(body), resolve)
+			}
+			continue
+		}
+
+		if idx := strings.Index(value, " #"); idx >= 0 {
+			value = value[:idx]
+		}
+		vars[key] = interpolate(strings.TrimSpace(value), resolve)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return vars, nil
+}
+
+// closingQuote returns the text before the closing quote character in s,
+// skipping backslash-escaped quotes inside double quotes
+func closingQuote(s string, quote byte) (string, bool) {
+	for i := 0; i < len(s); i++ {
+		if quote == '"' && s[i] == '\\' {
+			i++
+			continue
+		}
+		if s[i] == quote {
+			return s[:i], true
+		}
+	}
+	return "", false
+}
+
+// unescape handles the escapes allowed in double-quoted values
+func unescape(s string) string {
+	replacer := strings.NewReplacer(`\n`, "\n", `\r`, "\r", `\t`, "\t", `\"`, `"`, `\\`, `\`)
+	return replacer.Replace(s)
+}
+
+// interpolate expands ${VAR}, ${VAR:-default}, ${VAR-default} and $VAR.
+// "$$" yields a literal "$".
+func interpolate(s string, lookup func(string) (string, bool)) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' || i+1 == len(s) {
+			sb.WriteByte(s[i])
+			continue
+		}
+
+		switch next := s[i+1]; {
+		case next == '$':
+			sb.WriteByte('$')
+			i++
+		case next == '{':
+			end := strings.IndexByte(s[i:], '}')
+			if end < 0 {
+				sb.WriteString(s[i:])
+				return sb.String()
+			}
+			sb.WriteString(expandBraced(s[i+2:i+end], lookup))
+			i += end
+		case isEnvKeyStart(next):
+			j := i + 1
+			for j < len(s) && isEnvKeyChar(s[j]) {
+				j++
+			}
+			val, _ := lookup(s[i+1 : j])
+			sb.WriteString(val)
+			i = j - 1
+		default:
+			sb.WriteByte(s[i])
+		}
+	}
+	return sb.String()
+}
+
+// expandBraced expands the inside of ${...}
+func expandBraced(expr string, lookup func(string) (string, bool)) string {
+	if name, def, ok := strings.Cut(expr, ":-"); ok {
+		if val, found := lookup(name); found && val != "" {
+			return val
+		}
+		return def
+	}
+	if name, def, ok := strings.Cut(expr, "-"); ok {
+		if val, found := lookup(name); found {
+			return val
+		}
+		return def
+	}
+	val, _ := lookup(expr)
+	return val
+}
+
+func isValidEnvKey(key string) bool {
+	if key == "" || !isEnvKeyStart(key[0]) {
+		return false
+	}
+	for i := 1; i < len(key); i++ {
+		if !isEnvKeyChar(key[i]) && key[i] != '.' && key[i] != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+func isEnvKeyStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isEnvKeyChar(c byte) bool {
+	return isEnvKeyStart(c) || (c >= '0' && c <= '9')
+}
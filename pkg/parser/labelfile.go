@@ -0,0 +1,76 @@
+package parser
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kad/compose2podman/internal/types"
+)
+
+// loadLabelFiles reads the label_file entries of every service, resolving
+// paths relative to the compose file directory. Later files override
+// earlier ones; labels set in the service override all of them.
+func loadLabelFiles(compose *types.ComposeFile) error {
+	for name, service := range compose.Services {
+		files := service.LabelFiles()
+		if len(files) == 0 {
+			continue
+		}
+
+		service.LabelFileVars = make(map[string]string)
+		for _, file := range files {
+			path := file
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(compose.WorkingDir, path)
+			}
+
+			labels, err := readLabelFile(path)
+			if err != nil {
+				return fmt.Errorf("service %s: label_file %s: %w", name, file, err)
+			}
+			for key, val := range labels {
+				service.LabelFileVars[key] = val
+			}
+		}
+		compose.Services[name] = service
+	}
+	return nil
+}
+
+// nolint:gosec // G304: label_file paths come from the user's compose file
+func readLabelFile(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	return ParseLabelFile(f)
+}
+
+// ParseLabelFile parses a label file: one KEY=VALUE per line, taken
+// verbatim. Blank lines and lines starting with # are ignored and a key
+// without "=" gets an empty value.
+func ParseLabelFile(r io.Reader) (map[string]string, error) {
+	labels := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, val, _ := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if key == "" || strings.ContainsAny(key, " \t") {
+			return nil, fmt.Errorf("line %d: invalid label %q", lineNo, line)
+		}
+		labels[key] = val
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return labels, nil
+}
@@ -4,6 +4,7 @@ package parser
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 
 	"github.com/kad/compose2podman/internal/types"
 	"gopkg.in/yaml.v3"
@@ -34,5 +35,22 @@ func ParseComposeFile(filename string) (*types.ComposeFile, error) {
 		compose.Volumes = make(map[string]types.Volume)
 	}
 
+	// Relative paths in the file are resolved against its directory
+	absPath, err := filepath.Abs(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve compose file path: %w", err)
+	}
+	compose.WorkingDir = filepath.Dir(absPath)
+
+	if err := loadEnvFiles(&compose); err != nil {
+		return nil, err
+	}
+	if err := loadLabelFiles(&compose); err != nil {
+		return nil, err
+	}
+	if err := applyXPodman(&compose); err != nil {
+		return nil, err
+	}
+
 	return &compose, nil
 }
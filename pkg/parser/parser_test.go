@@ -2,7 +2,12 @@ package parser
 
 import (
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
+
+	"github.com/kad/compose2podman/internal/types"
 )
 
 func TestParseComposeFile(t *testing.T) {
@@ -91,3 +96,293 @@ func TestParseComposeFileInvalidYAML(t *testing.T) {
 		t.Error("Expected error for invalid YAML, got nil")
 	}
 }
+
+func TestResolveProjectName(t *testing.T) {
+	tests := []struct {
+		name     string
+		explicit string
+		env      string
+		topLevel string
+		filename string
+		expected string
+		wantErr  bool
+	}{
+		{"explicit wins", "flag_name", "env", "top", "/srv/dir/compose.yaml", "flag_name", false},
+		{"environment", "", "env-name", "top", "/srv/dir/compose.yaml", "env-name", false},
+		{"top-level name", "", "", "top", "/srv/dir/compose.yaml", "top", false},
+		{"directory fallback", "", "", "", "/srv/My App.v2/compose.yaml", "myappv2", false},
+		{"directory leading separators dropped", "", "", "", "/srv/__app/compose.yaml", "app", false},
+		{"uppercase explicit", "My.App", "", "", "/srv/dir/compose.yaml", "", true},
+		{"invalid explicit", "---", "", "", "/srv/dir/compose.yaml", "", true},
+		{"invalid environment", "", "Env", "top", "/srv/dir/compose.yaml", "", true},
+		{"invalid top-level name", "", "", "__app", "/srv/dir/compose.yaml", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(ProjectNameEnv, tt.env)
+			compose := &types.ComposeFile{Name: tt.topLevel}
+
+			result, err := ResolveProjectName(compose, tt.explicit, tt.filename)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ResolveProjectName() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if result != tt.expected {
+				t.Errorf("ResolveProjectName() = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseEnvFile(t *testing.T) {
+	content := `# comment
+export EXPORTED=1
+PLAIN = value # trailing comment
+EMPTY=
+SINGLE='literal $PLAIN # not a comment'
+DOUBLE="line1\nline2 ${PLAIN}"
+MULTI="first
+second"
+DEFAULT=${UNSET:-fallback}
+FROM_HOST
+MISSING_HOST
+ESCAPED=$$PLAIN
+`
+	lookup := func(key string) (string, bool) {
+		if key == "FROM_HOST" {
+			return "host-value", true
+		}
+		return "", false
+	}
+
+	vars, err := ParseEnvFile(strings.NewReader(content), "", lookup)
+	if err != nil {
+		t.Fatalf("ParseEnvFile failed: %v", err)
+	}
+
+	expected := map[string]string{
+		"EXPORTED":  "1",
+		"PLAIN":     "value",
+		"EMPTY":     "",
+		"SINGLE":    "literal $PLAIN # not a comment",
+		"DOUBLE":    "line1\nline2 value",
+		"MULTI":     "first\nsecond",
+		"DEFAULT":   "fallback",
+		"FROM_HOST": "host-value",
+		"ESCAPED":   "$PLAIN",
+	}
+	if len(vars) != len(expected) {
+		t.Errorf("Expected %d variables, got %v", len(expected), vars)
+	}
+	for key, val := range expected {
+		if vars[key] != val {
+			t.Errorf("For key %s: expected %q, got %q", key, val, vars[key])
+		}
+	}
+
+	raw, err := ParseEnvFile(strings.NewReader(`RAW="quoted ${X}"`+"\n"), "raw", lookup)
+	if err != nil {
+		t.Fatalf("ParseEnvFile raw failed: %v", err)
+	}
+	if raw["RAW"] != `"quoted ${X}"` {
+		t.Errorf("Raw format should keep the value verbatim, got %q", raw["RAW"])
+	}
+
+	if _, err := ParseEnvFile(strings.NewReader("BAD KEY=1\n"), "", lookup); err == nil {
+		t.Error("Expected error for invalid variable name")
+	}
+}
+
+func TestParseComposeFileEnvFile(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"compose.yaml": `services:
+  web:
+    image: nginx
+    env_file:
+      - .env.web
+      - path: ./optional.env
+        required: false
+    environment:
+      SHARED: from-environment
+`,
+		".env.web": "SHARED=from-file\nONLY_FILE=1\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	compose, err := ParseComposeFile(filepath.Join(dir, "compose.yaml"))
+	if err != nil {
+		t.Fatalf("ParseComposeFile failed: %v", err)
+	}
+
+	web := compose.Services["web"]
+	if len(web.EnvFilePaths) != 1 || web.EnvFilePaths[0] != filepath.Join(dir, ".env.web") {
+		t.Errorf("Expected resolved env file path, got %v", web.EnvFilePaths)
+	}
+	env, _ := web.ResolveEnvironment(true, nil)
+	if env["SHARED"] != "from-environment" || env["ONLY_FILE"] != "1" {
+		t.Errorf("environment should override env_file, got %v", env)
+	}
+
+	if len(web.EnvFileInline) != 0 {
+		t.Errorf("Plain values need no inlining, got %v", web.EnvFileInline)
+	}
+
+	// A missing required file is an error
+	if err := os.Remove(filepath.Join(dir, ".env.web")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseComposeFile(filepath.Join(dir, "compose.yaml")); err == nil {
+		t.Error("Expected error for missing required env_file")
+	}
+}
+
+func TestParseComposeFileEnvFileInline(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"compose.yaml": "services:\n  web:\n    image: nginx\n    env_file: [a.env, b.env]\n",
+		"a.env":        "PLAIN=1\nQUOTED=\"a b\"\nREF=${PLAIN}\nLATER='x'\n",
+		"b.env":        "LATER=y\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	compose, err := ParseComposeFile(filepath.Join(dir, "compose.yaml"))
+	if err != nil {
+		t.Fatalf("ParseComposeFile failed: %v", err)
+	}
+
+	expected := map[string]string{"QUOTED": "a b", "REF": "1"}
+	inline := compose.Services["web"].EnvFileInline
+	if len(inline) != len(expected) {
+		t.Errorf("Expected inline variables %v, got %v", expected, inline)
+	}
+	for key, val := range expected {
+		if inline[key] != val {
+			t.Errorf("Inline %s: expected %q, got %q", key, val, inline[key])
+		}
+	}
+}
+
+func TestParseComposeFileLabelFile(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"compose.yaml": `services:
+  web:
+    image: nginx
+    label_file: ./app.labels
+    labels:
+      - com.example.tier=web
+`,
+		"app.labels": "# labels\ncom.example.tier=file\ncom.example.owner=team a\ncom.example.flag\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	compose, err := ParseComposeFile(filepath.Join(dir, "compose.yaml"))
+	if err != nil {
+		t.Fatalf("ParseComposeFile failed: %v", err)
+	}
+
+	web := compose.Services["web"]
+	labels := web.LabelsMap()
+	expected := map[string]string{
+		"com.example.tier":  "web",
+		"com.example.owner": "team a",
+		"com.example.flag":  "",
+	}
+	if len(labels) != len(expected) {
+		t.Errorf("Expected %v, got %v", expected, labels)
+	}
+	for key, val := range expected {
+		if labels[key] != val {
+			t.Errorf("For label %s: expected %q, got %q", key, val, labels[key])
+		}
+	}
+}
+
+func TestParseComposeFileXPodman(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "compose.yaml")
+	content := `x-podman:
+  userns: keep-id
+  podman-args: ["--memory-swap=1g"]
+  quadlet:
+    Service:
+      Restart: always
+    Network:
+      DisableDNS: true
+services:
+  web:
+    image: nginx
+    x-podman:
+      notify: true
+      podman-args: ["--pids-limit=100"]
+      quadlet:
+        Service:
+          Restart: on-failure
+  db:
+    image: postgres
+    x-podman:
+      userns: auto
+`
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	compose, err := ParseComposeFile(path)
+	if err != nil {
+		t.Fatalf("ParseComposeFile failed: %v", err)
+	}
+
+	web := compose.Services["web"].XPodman
+	if web.UserNS != "keep-id" || web.Notify != "true" {
+		t.Errorf("Expected userns keep-id and notify true for web, got %+v", web)
+	}
+	if expected := []string{"--memory-swap=1g", "--pids-limit=100"}; !slices.Equal(web.PodmanArgs, expected) {
+		t.Errorf("Expected podman-args %v, got %v", expected, web.PodmanArgs)
+	}
+	if entries := web.QuadletEntries("Service"); !slices.Equal(entries, []string{"Restart=on-failure"}) {
+		t.Errorf("Expected the service to override Restart, got %v", entries)
+	}
+	if entries := web.QuadletEntries("Network"); len(entries) != 0 {
+		t.Errorf("Network keys belong to the top level only, got %v", entries)
+	}
+	if db := compose.Services["db"].XPodman; db.UserNS != "auto" || db.QuadletEntries("Service")[0] != "Restart=always" {
+		t.Errorf("Expected db to override userns and inherit Restart, got %+v", db)
+	}
+	if entries := compose.XPodman.QuadletEntries("Network"); !slices.Equal(entries, []string{"DisableDNS=true"}) {
+		t.Errorf("Expected top-level Network keys, got %v", entries)
+	}
+}
+
+func TestParseComposeFileXPodmanInvalid(t *testing.T) {
+	tests := map[string]string{
+		"unknown key":     "services:\n  web:\n    image: nginx\n    x-podman:\n      user-ns: keep-id\n",
+		"bad notify":      "services:\n  web:\n    image: nginx\n    x-podman:\n      notify: sometimes\n",
+		"service section": "services:\n  web:\n    image: nginx\n    x-podman:\n      quadlet:\n        Network:\n          Internal: true\n",
+		"top-level key":   "x-podman:\n  relabel: shared\nservices:\n  web:\n    image: nginx\n",
+	}
+
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "compose.yaml")
+			if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := ParseComposeFile(path); err == nil || !strings.Contains(err.Error(), "x-podman") {
+				t.Errorf("Expected an x-podman error, got %v", err)
+			}
+		})
+	}
+}
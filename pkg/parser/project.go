@@ -0,0 +1,57 @@
+package parser
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kad/compose2podman/internal/types"
+)
+
+// ProjectNameEnv is the environment variable Compose reads the project name from
+const ProjectNameEnv = "COMPOSE_PROJECT_NAME"
+
+// ResolveProjectName determines the project name the way Compose does:
+// the explicit name (from -p/--project-name) wins, then COMPOSE_PROJECT_NAME,
+// then the top-level name: field, and finally the name of the directory
+// containing the compose file. As in Compose, only the directory name is
+// normalized; a given name that is not valid as is is an error, since a
+// silently changed name would no longer match the units of the project.
+func ResolveProjectName(compose *types.ComposeFile, explicit, filename string) (string, error) {
+	candidates := []string{explicit, os.Getenv(ProjectNameEnv), compose.Name}
+	for _, candidate := range candidates {
+		if candidate == "" {
+			continue
+		}
+		if NormalizeProjectName(candidate) != candidate {
+			return "", fmt.Errorf("invalid project name %q: must consist of lowercase letters, digits, dashes and underscores and start with a letter or digit", candidate)
+		}
+		return candidate, nil
+	}
+
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return "", fmt.Errorf("failed to determine project directory: %w", err)
+	}
+	name := NormalizeProjectName(filepath.Base(filepath.Dir(abs)))
+	if name == "" {
+		return "", fmt.Errorf("cannot derive a project name from %s, use --project-name", filepath.Dir(abs))
+	}
+	return name, nil
+}
+
+// NormalizeProjectName lowercases name and drops characters Compose does not
+// allow in project names. Names must start with a letter or digit.
+func NormalizeProjectName(name string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			sb.WriteRune(r)
+		case (r == '-' || r == '_') && sb.Len() > 0:
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
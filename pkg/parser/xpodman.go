@@ -0,0 +1,27 @@
+package parser
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/kad/compose2podman/internal/types"
+)
+
+// applyXPodman validates the x-podman blocks against their schema and
+// completes the block of every service with the top-level defaults, so the
+// generators only need to look at the service
+func applyXPodman(compose *types.ComposeFile) error {
+	if err := compose.XPodman.Validate(true); err != nil {
+		return err
+	}
+	for _, name := range slices.Sorted(maps.Keys(compose.Services)) {
+		service := compose.Services[name]
+		if err := service.XPodman.Validate(false); err != nil {
+			return fmt.Errorf("service %s: %w", name, err)
+		}
+		service.XPodman = service.XPodman.WithDefaults(compose.XPodman)
+		compose.Services[name] = service
+	}
+	return nil
+}
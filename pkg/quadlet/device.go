@@ -0,0 +1,28 @@
+package quadlet
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kad/compose2podman/internal/types"
+)
+
+// writeDevices writes devices and CDI device requests as AddDevice= and
+// device_cgroup_rules as podman arguments, for which Quadlet has no key
+func (g *Generator) writeDevices(sb *strings.Builder, name string, service types.Service) {
+	for _, device := range service.DevicesList() {
+		sb.WriteString(fmt.Sprintf("AddDevice=%s\n", device))
+	}
+
+	cdi, errs := service.CDIDevices()
+	for _, device := range cdi {
+		sb.WriteString(fmt.Sprintf("AddDevice=%s\n", device))
+	}
+	for _, err := range errs {
+		g.warnf("service %s: %v; dropped", name, err)
+	}
+
+	for _, rule := range service.DeviceCgroupRules {
+		sb.WriteString(fmt.Sprintf("PodmanArgs=--device-cgroup-rule %s\n", quoteWord(rule)))
+	}
+}
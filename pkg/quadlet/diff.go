@@ -0,0 +1,151 @@
+package quadlet
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around each change
+const diffContext = 3
+
+type opKind byte
+
+const (
+	opEqual  opKind = ' '
+	opDelete opKind = '-'
+	opInsert opKind = '+'
+)
+
+type diffOp struct {
+	kind opKind
+	line string
+}
+
+// UnifiedDiff returns a unified diff between old and new content of path.
+// An empty old or new content is shown as /dev/null. It returns an empty
+// string when both are equal.
+func UnifiedDiff(path, old, new string) string {
+	if old == new {
+		return ""
+	}
+
+	var sb strings.Builder
+	from, to := "a/"+strings.TrimPrefix(path, "/"), "b/"+strings.TrimPrefix(path, "/")
+	if old == "" {
+		from = "/dev/null"
+	}
+	if new == "" {
+		to = "/dev/null"
+	}
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", from, to)
+
+	ops := diffLines(splitLines(old), splitLines(new))
+	for _, h := range hunks(ops) {
+		writeHunk(&sb, ops, h[0], h[1])
+	}
+	return sb.String()
+}
+
+// splitLines splits content into lines without their trailing newline
+func splitLines(content string) []string {
+	if content == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+}
+
+// diffLines computes a line-based edit script using the longest common
+// subsequence. Unit files are small, so the quadratic table is fine.
+func diffLines(a, b []string) []diffOp {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	ops := make([]diffOp, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, diffOp{opEqual, a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{opDelete, a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{opInsert, b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, diffOp{opDelete, a[i]})
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, diffOp{opInsert, b[j]})
+	}
+	return ops
+}
+
+// hunks groups changed operations with their context into [start, end)
+// ranges of ops, merging ranges whose context overlaps
+func hunks(ops []diffOp) [][2]int {
+	var result [][2]int
+	for i, op := range ops {
+		if op.kind == opEqual {
+			continue
+		}
+		start := max(i-diffContext, 0)
+		end := min(i+diffContext+1, len(ops))
+		if n := len(result); n > 0 && start <= result[n-1][1] {
+			result[n-1][1] = end
+			continue
+		}
+		result = append(result, [2]int{start, end})
+	}
+	return result
+}
+
+// writeHunk writes ops[start:end] with a @@ header giving line positions
+func writeHunk(sb *strings.Builder, ops []diffOp, start, end int) {
+	oldLine, newLine := 1, 1
+	for _, op := range ops[:start] {
+		if op.kind != opInsert {
+			oldLine++
+		}
+		if op.kind != opDelete {
+			newLine++
+		}
+	}
+
+	oldCount, newCount := 0, 0
+	for _, op := range ops[start:end] {
+		if op.kind != opInsert {
+			oldCount++
+		}
+		if op.kind != opDelete {
+			newCount++
+		}
+	}
+	// An empty range is reported as starting at the line before it
+	if oldCount == 0 {
+		oldLine--
+	}
+	if newCount == 0 {
+		newLine--
+	}
+
+	fmt.Fprintf(sb, "@@ -%d,%d +%d,%d @@\n", oldLine, oldCount, newLine, newCount)
+	for _, op := range ops[start:end] {
+		fmt.Fprintf(sb, "%c%s\n", op.kind, op.line)
+	}
+}
@@ -0,0 +1,42 @@
+package quadlet
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kad/compose2podman/internal/types"
+)
+
+// writeDNS writes hostname, domainname, dns, dns_search, dns_opt and
+// extra_hosts
+func (g *Generator) writeDNS(sb *strings.Builder, name string, service types.Service) {
+	// Podman has no separate domain name, so it becomes part of the
+	// host name as a fully qualified name
+	hostname := service.Hostname
+	if service.Domainname != "" {
+		if hostname == "" {
+			hostname = name
+		}
+		hostname += "." + service.Domainname
+		g.warnf("service %s: domainname is not supported by Podman; using host name %s", name, hostname)
+	}
+	if hostname != "" {
+		sb.WriteString(fmt.Sprintf("HostName=%s\n", hostname))
+	}
+
+	for _, server := range service.DNSList() {
+		sb.WriteString(fmt.Sprintf("DNS=%s\n", server))
+	}
+	for _, domain := range service.DNSSearchList() {
+		sb.WriteString(fmt.Sprintf("DNSSearch=%s\n", domain))
+	}
+	for _, option := range service.DNSOpt {
+		sb.WriteString(fmt.Sprintf("DNSOption=%s\n", option))
+	}
+
+	// host-gateway is resolved by Podman to the address of
+	// host.containers.internal
+	for _, host := range service.ExtraHostsList() {
+		sb.WriteString(fmt.Sprintf("AddHost=%s:%s\n", host.Host, host.IP))
+	}
+}
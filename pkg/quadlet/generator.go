@@ -4,109 +4,345 @@ package quadlet
 
 import (
 	"fmt"
-	"os"
-	"path/filepath"
+	"maps"
+	"slices"
+	"sort"
 	"strings"
 
 	"github.com/kad/compose2podman/internal/types"
 )
 
+// ManagedHeader is written as the first line of every generated file. It is
+// used to recognize files owned by compose2podman when pruning stale units.
+const ManagedHeader = "# Generated by compose2podman. Do not edit; changes will be overwritten."
+
+// projectHeaderPrefix starts the second header line naming the project, so
+// pruning never touches units of another project sharing the directory
+const projectHeaderPrefix = "# Project: "
+
+// Options configures optional behavior of the Quadlet generator
+type Options struct {
+	// EnvFileMode selects whether env_file variables are written as
+	// Environment= lines (default) or referenced with EnvironmentFile=
+	EnvFileMode types.EnvFileMode
+
+	// LookupEnv resolves environment variables declared without a value,
+	// typically os.LookupEnv. Nil resolves nothing.
+	LookupEnv func(string) (string, bool)
+
+	// EnvPassthrough writes variables declared without a value as
+	// PodmanArgs=--env KEY, so they are taken from the environment of the
+	// unit at run time instead of being resolved during conversion
+	EnvPassthrough bool
+
+	// AutoUpdate is the podman auto-update policy, registry or local, of
+	// services that do not set x-podman.auto-update. Empty disables it.
+	AutoUpdate string
+
+	// ImageUnits writes a .image unit for every distinct image, so images
+	// are pulled once before the containers start, and makes containers
+	// refer to it with Image=<name>.image
+	ImageUnits bool
+
+	// AuthFile and TLSVerify are written to the .image units, if set
+	AuthFile  string
+	TLSVerify *bool
+
+	// Kube runs all services as one pod with podman kube play: the pod is
+	// written as Kubernetes YAML with a .kube unit instead of a .container
+	// unit per service
+	Kube bool
+
+	// PodName names the pod, its YAML and its .kube unit in Kube mode.
+	// Empty uses the project name.
+	PodName string
+
+	// ExitCodePropagation is written to the .kube unit: all, any or none.
+	// Empty leaves the Quadlet default.
+	ExitCodePropagation string
+}
+
 // Generator generates Podman Quadlet files
 type Generator struct {
 	compose   *types.ComposeFile
 	outputDir string
+	opts      Options
+	warnings  []string
 }
 
 // NewGenerator creates a new Quadlet generator
 func NewGenerator(compose *types.ComposeFile, outputDir string) *Generator {
+	return NewGeneratorWithOptions(compose, outputDir, Options{})
+}
+
+// NewGeneratorWithOptions creates a new Quadlet generator with options
+func NewGeneratorWithOptions(compose *types.ComposeFile, outputDir string, opts Options) *Generator {
 	return &Generator{
 		compose:   compose,
 		outputDir: outputDir,
+		opts:      opts,
 	}
 }
 
 // Generate creates Quadlet files (.container, .volume, .network)
 func (g *Generator) Generate() error {
-	// Create output directory with standard permissions
-	//nolint:gosec // G301: Standard directory permissions for systemd unit files
-	if err := os.MkdirAll(g.outputDir, 0755); err != nil {
-		return fmt.Errorf("failed to create output directory: %w", err)
+	plan, err := g.Plan()
+	if err != nil {
+		return err
+	}
+	return g.Apply(plan, false)
+}
+
+// Render returns the content of every Quadlet file keyed by file name,
+// without touching the output directory.
+func (g *Generator) Render() (map[string]string, error) {
+	files := make(map[string]string)
+	g.warnings = nil
+
+	// Generate network files; external networks are created outside the project
+	for _, name := range sortedKeys(g.compose.Networks) {
+		network := g.compose.Networks[name]
+		if network.External {
+			continue
+		}
+		files[g.networkFile(name)] = g.generateNetwork(name, network)
+	}
+
+	if g.opts.Kube {
+		if err := g.renderKube(files); err != nil {
+			return nil, err
+		}
+		return files, nil
 	}
 
-	// Generate network files
-	for name, network := range g.compose.Networks {
-		if err := g.generateNetwork(name, network); err != nil {
-			return err
+	// Generate volume files; external volumes are created outside the project
+	for _, name := range sortedKeys(g.compose.Volumes) {
+		volume := g.compose.Volumes[name]
+		if volume.External {
+			continue
 		}
+		files[g.volumeFile(name)] = g.generateVolume(name, volume)
 	}
 
-	// Generate volume files
-	for name, volume := range g.compose.Volumes {
-		if err := g.generateVolume(name, volume); err != nil {
-			return err
+	// Generate image files, shared by the services using the same image
+	if g.opts.ImageUnits {
+		images, err := g.generateImages()
+		if err != nil {
+			return nil, err
 		}
+		maps.Copy(files, images)
 	}
 
 	// Generate container files
-	for name, service := range g.compose.Services {
-		if err := g.generateContainer(name, service); err != nil {
-			return err
+	for _, name := range sortedKeys(g.compose.Services) {
+		content, err := g.generateContainer(name, g.compose.Services[name])
+		if err != nil {
+			return nil, err
 		}
+		files[g.containerFile(name)] = content
+	}
+
+	return files, nil
+}
+
+// Warnings returns the conversion problems found by the last Render, such as
+// Compose settings that have no Podman equivalent and were dropped
+func (g *Generator) Warnings() []string {
+	return g.warnings
+}
+
+func (g *Generator) warnf(format string, args ...interface{}) {
+	g.warnings = append(g.warnings, fmt.Sprintf(format, args...))
+}
+
+// header returns the managed-by header including the project line
+func (g *Generator) header() string {
+	if g.compose.Name == "" {
+		return ManagedHeader + "\n"
+	}
+	return ManagedHeader + "\n" + projectHeaderPrefix + g.compose.Name + "\n"
+}
+
+// containerFile returns the unit file name of a service, <project>-<service>.container
+func (g *Generator) containerFile(name string) string {
+	if g.compose.Name == "" {
+		return name + ".container"
 	}
+	return fmt.Sprintf("%s-%s.container", g.compose.Name, name)
+}
 
-	return nil
+// networkFile returns the unit file name of a network, named like the Podman network
+func (g *Generator) networkFile(name string) string {
+	return g.compose.NetworkName(name) + ".network"
 }
 
-func (g *Generator) generateContainer(name string, service types.Service) error {
+// networkRef returns the value used in Network= for a service network:
+// the .network unit, or the plain Podman network name for external networks
+func (g *Generator) networkRef(name string) string {
+	if g.compose.Networks[name].External {
+		return g.compose.NetworkName(name)
+	}
+	return g.networkFile(name)
+}
+
+// volumeFile returns the unit file name of a volume, named like the Podman volume
+func (g *Generator) volumeFile(name string) string {
+	return g.compose.VolumeName(name) + ".volume"
+}
+
+// volumeSource maps the source of a service volume to the .volume unit when
+// it refers to a volume declared at the top level, or to the plain Podman
+// volume name for external volumes
+func (g *Generator) volumeSource(vol string) string {
+	source, rest, found := strings.Cut(vol, ":")
+	if !found {
+		return vol
+	}
+	volume, declared := g.compose.Volumes[source]
+	switch {
+	case !declared:
+		return vol
+	case volume.External:
+		return g.compose.VolumeName(source) + ":" + rest
+	default:
+		return g.volumeFile(source) + ":" + rest
+	}
+}
+
+// writeLabels writes the project labels followed by the resource labels
+func writeLabels(sb *strings.Builder, project, labels map[string]string) {
+	for _, key := range sortedKeys(project) {
+		sb.WriteString(fmt.Sprintf("Label=%s\n", quoteWord(key+"="+project[key])))
+	}
+	for _, key := range sortedKeys(labels) {
+		if _, ok := project[key]; ok {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("Label=%s\n", quoteWord(key+"="+labels[key])))
+	}
+}
+
+func (g *Generator) generateContainer(name string, service types.Service) (string, error) {
 	var sb strings.Builder
 
+	sb.WriteString(g.header())
+
 	sb.WriteString("[Unit]\n")
 	sb.WriteString(fmt.Sprintf("Description=%s container\n", name))
 
-	// Handle dependencies
+	network, err := g.networkMode(name, service)
+	if err != nil {
+		return "", err
+	}
+	restart, err := service.RestartSettings()
+	if err != nil {
+		return "", fmt.Errorf("service %s: %w", name, err)
+	}
+	grace, graceSet, err := service.StopGracePeriodDuration()
+	if err != nil {
+		return "", fmt.Errorf("service %s: %w", name, err)
+	}
+
+	// Handle dependencies; a shared namespace needs its owner running
 	deps := service.DependsOnList()
+	for _, owner := range service.NamespaceServices() {
+		if !slices.Contains(deps, owner) {
+			deps = append(deps, owner)
+		}
+	}
 	if len(deps) > 0 {
 		after := make([]string, 0, len(deps))
 		for _, dep := range deps {
-			after = append(after, fmt.Sprintf("%s.service", dep))
+			after = append(after, ServiceName(g.containerFile(dep)))
 		}
 		sb.WriteString(fmt.Sprintf("After=%s\n", strings.Join(after, " ")))
 		sb.WriteString(fmt.Sprintf("Requires=%s\n", strings.Join(after, " ")))
 	}
+	writeStartLimit(&sb, restart)
+	writeRaw(&sb, service.XPodman, "Unit")
 
 	sb.WriteString("\n[Container]\n")
 
-	if service.Image != "" {
-		sb.WriteString(fmt.Sprintf("Image=%s\n", service.Image))
+	if err := g.writeImage(&sb, name, service); err != nil {
+		return "", err
 	}
 
 	// Container name
-	containerName := name
-	if service.ContainerName != "" {
-		containerName = service.ContainerName
-	}
+	containerName := g.compose.ContainerName(name, service)
 	sb.WriteString(fmt.Sprintf("ContainerName=%s\n", containerName))
 
-	// Environment variables
-	env := service.EnvironmentMap()
-	for key, val := range env {
-		sb.WriteString(fmt.Sprintf("Environment=%s=%s\n", key, val))
+	// Environment variables; environment overrides env_file in both modes
+	// since Podman applies --env after --env-file
+	reference := g.opts.EnvFileMode == types.EnvFileReference
+	lookup := g.opts.LookupEnv
+	if g.opts.EnvPassthrough {
+		lookup = nil
+	}
+	env, unresolved := service.ResolveEnvironment(!reference, lookup)
+	if reference {
+		for _, path := range service.EnvFilePaths {
+			sb.WriteString(fmt.Sprintf("EnvironmentFile=%s\n", path))
+		}
+		// Podman reads env files verbatim, so values relying on Compose
+		// quoting or interpolation are written inline
+		for _, key := range sortedKeys(service.EnvFileInline) {
+			if _, ok := env[key]; ok {
+				continue
+			}
+			g.warnf("service %s: env_file variable %s uses quoting or interpolation Podman does not apply; written inline", name, key)
+			env[key] = service.EnvFileInline[key]
+		}
+	}
+	for _, key := range sortedKeys(env) {
+		sb.WriteString(fmt.Sprintf("Environment=%s\n", quoteWord(key+"="+env[key])))
+	}
+	for _, key := range unresolved {
+		if _, ok := service.EnvFileVars[key]; ok && reference {
+			continue
+		}
+		if g.opts.EnvPassthrough {
+			sb.WriteString(fmt.Sprintf("PodmanArgs=--env %s\n", key))
+			continue
+		}
+		g.warnf("service %s: environment variable %s has no value and is not set; omitted", name, key)
 	}
 
-	// Ports
-	for _, port := range service.Ports {
-		sb.WriteString(fmt.Sprintf("PublishPort=%s\n", port))
+	// Ports, unless the network mode cannot publish them
+	if publishesPorts(service.NetworkMode) {
+		for _, port := range service.Ports {
+			sb.WriteString(fmt.Sprintf("PublishPort=%s\n", port))
+		}
 	}
 
 	// Volumes
 	for _, vol := range service.Volumes {
-		sb.WriteString(fmt.Sprintf("Volume=%s\n", vol))
+		if service.XPodman != nil {
+			vol = relabelVolume(vol, service.XPodman.Relabel)
+		}
+		sb.WriteString(fmt.Sprintf("Volume=%s\n", g.volumeSource(vol)))
+	}
+
+	// Devices
+	g.writeDevices(&sb, name, service)
+
+	// Tmpfs, shared memory and kernel limits
+	if err := g.writeResources(&sb, name, service); err != nil {
+		return "", err
 	}
 
 	// Networks
-	networks := service.NetworksList()
-	for _, net := range networks {
-		sb.WriteString(fmt.Sprintf("Network=%s.network\n", net))
+	network = g.portHandlerNetwork(name, service, network)
+	if network != "" {
+		sb.WriteString(fmt.Sprintf("Network=%s\n", network))
+	} else {
+		for _, attachment := range service.NetworkAttachments() {
+			sb.WriteString(fmt.Sprintf("Network=%s\n", g.networkAttachment(name, attachment)))
+		}
+
+		// Keep the service name resolvable when the container is named differently
+		if containerName != name {
+			sb.WriteString(fmt.Sprintf("NetworkAlias=%s\n", name))
+		}
 	}
 
 	// Working directory
@@ -124,109 +360,63 @@ func (g *Generator) generateContainer(name string, service types.Service) error
 		sb.WriteString(fmt.Sprintf("Exec=%s\n", strings.Join(cmd, " ")))
 	}
 
-	// Hostname
-	if service.Hostname != "" {
-		sb.WriteString(fmt.Sprintf("HostName=%s\n", service.Hostname))
-	}
-
-	// Privileged
-	if service.Privileged {
-		sb.WriteString("SecurityLabelDisable=true\n")
-	}
+	// Hostname and name resolution
+	g.writeDNS(&sb, name, service)
 
-	// Capabilities
-	for _, cap := range service.CapAdd {
-		sb.WriteString(fmt.Sprintf("AddCapability=%s\n", cap))
-	}
-	for _, cap := range service.CapDrop {
-		sb.WriteString(fmt.Sprintf("DropCapability=%s\n", cap))
+	// Terminal and namespaces
+	if err := g.writeNamespaces(&sb, name, service); err != nil {
+		return "", err
 	}
 
-	// Labels
-	for key, val := range service.Labels {
-		sb.WriteString(fmt.Sprintf("Label=%s=%s\n", key, val))
-	}
+	// Logging
+	g.writeLogging(&sb, name, service)
 
-	sb.WriteString("\n[Service]\n")
+	// Stop behavior and init
+	writeStop(&sb, service, grace, graceSet)
 
-	// Map Docker Compose restart policies to systemd
-	restart := "always"
-	switch service.Restart {
-	case "no":
-		restart = "no"
-	case "always":
-		restart = "always"
-	case "on-failure":
-		restart = "on-failure"
-	case "unless-stopped":
-		restart = "always"
-	}
-	sb.WriteString(fmt.Sprintf("Restart=%s\n", restart))
-	sb.WriteString("TimeoutStartSec=900\n")
+	// Privileges, capabilities and security options
+	g.writeSecurity(&sb, service)
 
-	sb.WriteString("\n[Install]\n")
-	sb.WriteString("WantedBy=default.target\n")
+	// Podman settings Compose has no attribute for
+	writeXPodman(&sb, service)
 
-	filename := filepath.Join(g.outputDir, fmt.Sprintf("%s.container", name))
-	if err := os.WriteFile(filename, []byte(sb.String()), 0644); err != nil {
-		return fmt.Errorf("failed to write container file: %w", err)
+	// Labels and annotations
+	writeLabels(&sb, g.compose.ProjectLabels(), service.LabelsMap())
+	annotations := service.AnnotationsMap()
+	for _, key := range sortedKeys(annotations) {
+		sb.WriteString(fmt.Sprintf("Annotation=%s\n", quoteWord(key+"="+annotations[key])))
 	}
+	writeRaw(&sb, service.XPodman, "Container")
 
-	return nil
-}
-
-func (g *Generator) generateVolume(name string, volume types.Volume) error {
-	var sb strings.Builder
-
-	sb.WriteString("[Unit]\n")
-	sb.WriteString(fmt.Sprintf("Description=%s volume\n", name))
+	sb.WriteString("\n[Service]\n")
 
-	sb.WriteString("\n[Volume]\n")
+	// Map Docker Compose restart policies to systemd
+	writeRestart(&sb, restart)
 
-	if volume.Driver != "" && volume.Driver != "local" {
-		sb.WriteString(fmt.Sprintf("Driver=%s\n", volume.Driver))
+	// Lifecycle hooks run in the container with podman exec
+	if err := writeHooks(&sb, name, containerName, service); err != nil {
+		return "", err
 	}
-
-	// Labels
-	for key, val := range volume.Labels {
-		sb.WriteString(fmt.Sprintf("Label=%s=%s\n", key, val))
+	sb.WriteString("TimeoutStartSec=900\n")
+	if graceSet {
+		sb.WriteString(fmt.Sprintf("TimeoutStopSec=%d\n", stopSeconds(grace)+stopTimeoutMargin))
 	}
+	writeRaw(&sb, service.XPodman, "Service")
 
 	sb.WriteString("\n[Install]\n")
 	sb.WriteString("WantedBy=default.target\n")
+	writeRaw(&sb, service.XPodman, "Install")
 
-	filename := filepath.Join(g.outputDir, fmt.Sprintf("%s.volume", name))
-	if err := os.WriteFile(filename, []byte(sb.String()), 0644); err != nil {
-		return fmt.Errorf("failed to write volume file: %w", err)
-	}
-
-	return nil
+	return sb.String(), nil
 }
 
-func (g *Generator) generateNetwork(name string, network types.Network) error {
-	var sb strings.Builder
-
-	sb.WriteString("[Unit]\n")
-	sb.WriteString(fmt.Sprintf("Description=%s network\n", name))
-
-	sb.WriteString("\n[Network]\n")
-
-	if network.Driver != "" {
-		sb.WriteString(fmt.Sprintf("Driver=%s\n", network.Driver))
+// sortedKeys returns the keys of m in lexical order so that generated files
+// are stable across runs and can be diffed meaningfully.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
 	}
-
-	// Labels
-	for key, val := range network.Labels {
-		sb.WriteString(fmt.Sprintf("Label=%s=%s\n", key, val))
-	}
-
-	sb.WriteString("\n[Install]\n")
-	sb.WriteString("WantedBy=default.target\n")
-
-	filename := filepath.Join(g.outputDir, fmt.Sprintf("%s.network", name))
-	if err := os.WriteFile(filename, []byte(sb.String()), 0644); err != nil {
-		return fmt.Errorf("failed to write network file: %w", err)
-	}
-
-	return nil
+	sort.Strings(keys)
+	return keys
 }
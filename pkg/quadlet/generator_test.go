@@ -0,0 +1,1262 @@
+package quadlet
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/kad/compose2podman/internal/types"
+)
+
+func TestGenerate(t *testing.T) {
+	compose := &types.ComposeFile{
+		Services: map[string]types.Service{
+			"web": {
+				Image: "nginx:latest",
+				Ports: []string{"8080:80"},
+			},
+		},
+		Networks: map[string]types.Network{
+			"frontend": {Driver: "bridge"},
+		},
+	}
+
+	dir := t.TempDir()
+	if err := NewGenerator(compose, dir).Generate(); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "web.container"))
+	if err != nil {
+		t.Fatalf("Expected web.container: %v", err)
+	}
+	content := string(data)
+
+	if !strings.HasPrefix(content, ManagedHeader+"\n") {
+		t.Error("Generated file should start with the managed-by header")
+	}
+	if !strings.Contains(content, "Image=nginx:latest") {
+		t.Error("Generated file should contain image")
+	}
+	if !strings.Contains(content, "PublishPort=8080:80") {
+		t.Error("Generated file should contain port")
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "frontend.network")); err != nil {
+		t.Errorf("Expected frontend.network: %v", err)
+	}
+}
+
+func TestPlanAndPrune(t *testing.T) {
+	compose := &types.ComposeFile{
+		Services: map[string]types.Service{
+			"web": {Image: "nginx:latest"},
+			"api": {Image: "node:18"},
+		},
+	}
+
+	dir := t.TempDir()
+	if err := NewGenerator(compose, dir).Generate(); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	// A file not written by us must never be touched
+	manual := filepath.Join(dir, "manual.container")
+	if err := os.WriteFile(manual, []byte("[Container]\nImage=busybox\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Drop the api service and change the web image
+	compose.Services = map[string]types.Service{
+		"web": {Image: "nginx:1.27"},
+	}
+	gen := NewGenerator(compose, dir)
+	plan, err := gen.Plan()
+	if err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+
+	actions := make(map[string]Action)
+	for _, change := range plan.Changes {
+		actions[change.Name] = change.Action
+	}
+	expected := map[string]Action{
+		"web.container": ActionUpdate,
+		"api.container": ActionRemove,
+	}
+	if len(actions) != len(expected) {
+		t.Errorf("Expected %d changes, got %v", len(expected), actions)
+	}
+	for name, action := range expected {
+		if actions[name] != action {
+			t.Errorf("For %s: expected action '%s', got '%s'", name, action, actions[name])
+		}
+	}
+
+	if !strings.Contains(plan.Diff(false), "+Image=nginx:1.27") {
+		t.Errorf("Diff should contain the new image, got:\n%s", plan.Diff(false))
+	}
+	if strings.Contains(plan.Diff(false), "api.container") {
+		t.Errorf("Diff without prune should not remove stale files, got:\n%s", plan.Diff(false))
+	}
+	if !strings.Contains(plan.Diff(true), "-Image=node:18") {
+		t.Errorf("Diff with prune should remove stale files, got:\n%s", plan.Diff(true))
+	}
+
+	// Without prune stale files are kept
+	if err := gen.Apply(plan, false); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "api.container")); err != nil {
+		t.Error("Stale file should be kept without prune")
+	}
+
+	if err := gen.Apply(plan, true); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "api.container")); !os.IsNotExist(err) {
+		t.Error("Stale file should be removed with prune")
+	}
+	if _, err := os.Stat(manual); err != nil {
+		t.Error("Unmanaged file should never be removed")
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		old      string
+		new      string
+		expected string
+	}{
+		{
+			name:     "equal",
+			old:      "a\nb\n",
+			new:      "a\nb\n",
+			expected: "",
+		},
+		{
+			name:     "new file",
+			old:      "",
+			new:      "a\nb\n",
+			expected: "--- /dev/null\n+++ b/f\n@@ -0,0 +1,2 @@\n+a\n+b\n",
+		},
+		{
+			name:     "changed line",
+			old:      "a\nb\nc\n",
+			new:      "a\nx\nc\n",
+			expected: "--- a/f\n+++ b/f\n@@ -1,3 +1,3 @@\n a\n-b\n+x\n c\n",
+		},
+		{
+			name:     "separate hunks",
+			old:      "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+			new:      "0\n1\n2\n3\n4\n5\n6\n7\n8\n9\n",
+			expected: "--- a/f\n+++ b/f\n@@ -1,3 +1,4 @@\n+0\n 1\n 2\n 3\n@@ -7,4 +8,3 @@\n 7\n 8\n 9\n-10\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := UnifiedDiff("f", tt.old, tt.new)
+			if result != tt.expected {
+				t.Errorf("UnifiedDiff() =\n%s\nwant:\n%s", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGenerateProjectNaming(t *testing.T) {
+	compose := &types.ComposeFile{
+		Name: "shop",
+		Services: map[string]types.Service{
+			"web": {
+				Image:     "nginx:latest",
+				Volumes:   []string{"static:/usr/share/nginx/html", "./conf:/etc/nginx/conf.d"},
+				Networks:  []interface{}{"frontend"},
+				DependsOn: []interface{}{"api"},
+			},
+			"api": {Image: "node:18", ContainerName: "api"},
+		},
+		Networks: map[string]types.Network{"frontend": {}},
+		Volumes:  map[string]types.Volume{"static": {}},
+	}
+
+	files, err := NewGenerator(compose, t.TempDir()).Render()
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+
+	for _, name := range []string{"shop-web.container", "shop-api.container", "shop_frontend.network", "shop_static.volume"} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("Expected file %s, got %v", name, sortedKeys(files))
+		}
+	}
+
+	web := files["shop-web.container"]
+	for _, line := range []string{
+		"# Project: shop",
+		"After=shop-api.service",
+		"ContainerName=shop-web-1",
+		"NetworkAlias=web",
+		"Network=shop_frontend.network",
+		"Volume=shop_static.volume:/usr/share/nginx/html",
+		"Volume=./conf:/etc/nginx/conf.d",
+		"Label=com.docker.compose.project=shop",
+		"Label=io.podman.compose.project=shop",
+	} {
+		if !strings.Contains(web, line+"\n") {
+			t.Errorf("Expected %q in web.container:\n%s", line, web)
+		}
+	}
+
+	if !strings.Contains(files["shop-api.container"], "ContainerName=api\n") {
+		t.Error("Explicit container_name should be kept")
+	}
+	if !strings.Contains(files["shop_frontend.network"], "NetworkName=shop_frontend\n") {
+		t.Error("Network should use the project-scoped name")
+	}
+	if !strings.Contains(files["shop_static.volume"], "VolumeName=shop_static\n") {
+		t.Error("Volume should use the project-scoped name")
+	}
+}
+
+func TestPruneKeepsOtherProjects(t *testing.T) {
+	dir := t.TempDir()
+	other := &types.ComposeFile{Name: "other", Services: map[string]types.Service{"db": {Image: "postgres"}}}
+	if err := NewGenerator(other, dir).Generate(); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	compose := &types.ComposeFile{Name: "shop", Services: map[string]types.Service{"web": {Image: "nginx"}}}
+	plan, err := NewGenerator(compose, dir).Plan()
+	if err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+	for _, change := range plan.Changes {
+		if change.Action == ActionRemove {
+			t.Errorf("File of another project should not be pruned: %s", change.Name)
+		}
+	}
+}
+
+func TestGenerateNetworkOptions(t *testing.T) {
+	compose := &types.ComposeFile{
+		Name: "shop",
+		Services: map[string]types.Service{
+			"web": {Image: "nginx", Networks: []interface{}{"backend", "shared"}},
+		},
+		Networks: map[string]types.Network{
+			"backend": {
+				Internal:   true,
+				EnableIPv6: true,
+				DriverOpts: map[string]string{
+					"com.docker.network.bridge.name":       "br-shop",
+					"com.docker.network.driver.mtu":        "1400",
+					"com.docker.network.bridge.enable_icc": "true",
+					"isolate":                              "true",
+				},
+				IPAM: types.IPAM{Config: []types.IPAMConfig{{
+					Subnet:       "10.5.0.0/16",
+					Gateway:      "10.5.0.1",
+					IPRange:      "10.5.1.0/24",
+					AuxAddresses: map[string]string{"router": "10.5.0.2"},
+				}}},
+			},
+			"shared":  {External: true},
+			"renamed": {Name: "custom-net"},
+		},
+	}
+
+	gen := NewGenerator(compose, t.TempDir())
+	files, err := gen.Render()
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+
+	if _, ok := files["shared.network"]; ok {
+		t.Error("External network should not get a .network file")
+	}
+	if _, ok := files["custom-net.network"]; !ok {
+		t.Error("Network with explicit name should use that name")
+	}
+
+	backend := files["shop_backend.network"]
+	for _, line := range []string{
+		"NetworkName=shop_backend",
+		"Internal=true",
+		"IPv6=true",
+		"Subnet=10.5.0.0/16",
+		"Gateway=10.5.0.1",
+		"IPRange=10.5.1.0/24",
+		"InterfaceName=br-shop",
+		"Options=mtu=1400",
+		"Options=isolate=true",
+	} {
+		if !strings.Contains(backend, line+"\n") {
+			t.Errorf("Expected %q in backend network:\n%s", line, backend)
+		}
+	}
+	if strings.Contains(backend, "enable_icc") {
+		t.Error("Unsupported Docker option should be dropped")
+	}
+	if len(gen.Warnings()) != 2 {
+		t.Errorf("Expected 2 warnings, got %v", gen.Warnings())
+	}
+
+	web := files["shop-web.container"]
+	if !strings.Contains(web, "Network=shop_backend.network\n") || !strings.Contains(web, "Network=shared\n") {
+		t.Errorf("Expected unit and external network references:\n%s", web)
+	}
+}
+
+func TestGenerateNetworkAttachments(t *testing.T) {
+	compose := &types.ComposeFile{
+		Services: map[string]types.Service{
+			"api": {
+				Image: "node:18",
+				Networks: map[string]interface{}{
+					"frontend": map[string]interface{}{
+						"aliases":      []interface{}{"api-public"},
+						"ipv4_address": "10.0.0.5",
+					},
+					"backend": nil,
+				},
+			},
+		},
+		Networks: map[string]types.Network{"frontend": {}, "backend": {}},
+	}
+
+	files, err := NewGenerator(compose, t.TempDir()).Render()
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+
+	api := files["api.container"]
+	if !strings.Contains(api, "Network=frontend.network:alias=api-public,ip=10.0.0.5\n") {
+		t.Errorf("Expected network options in api.container:\n%s", api)
+	}
+	if !strings.Contains(api, "Network=backend.network\n") {
+		t.Errorf("Expected plain network reference in api.container:\n%s", api)
+	}
+}
+
+func TestGenerateNetworkMode(t *testing.T) {
+	compose := &types.ComposeFile{
+		Name: "shop",
+		Services: map[string]types.Service{
+			"vpn":     {Image: "wireguard", NetworkMode: "host"},
+			"app":     {Image: "app", NetworkMode: "service:vpn", Ports: []string{"8080:80"}},
+			"isolate": {Image: "batch", NetworkMode: "none"},
+			"sidecar": {Image: "proxy", NetworkMode: "container:external-ctr"},
+			"tunnel":  {Image: "proxy", NetworkMode: "pasta:-T,5000", Ports: []string{"9090:90"}},
+		},
+	}
+
+	gen := NewGenerator(compose, t.TempDir())
+	files, err := gen.Render()
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+
+	tests := map[string][]string{
+		"shop-vpn.container":     {"Network=host"},
+		"shop-app.container":     {"Network=container:shop-vpn-1", "After=shop-vpn.service", "Requires=shop-vpn.service"},
+		"shop-isolate.container": {"Network=none"},
+		"shop-sidecar.container": {"Network=container:external-ctr"},
+		"shop-tunnel.container":  {"Network=pasta:-T,5000", "PublishPort=9090:90"},
+	}
+	for file, lines := range tests {
+		for _, line := range lines {
+			if !strings.Contains(files[file], line+"\n") {
+				t.Errorf("Expected %q in %s:\n%s", line, file, files[file])
+			}
+		}
+		if strings.Contains(files[file], "NetworkAlias=") {
+			t.Errorf("%s should not set network aliases with network_mode", file)
+		}
+	}
+	if strings.Contains(files["shop-app.container"], "PublishPort=") {
+		t.Errorf("Ports should not be published when sharing a network:\n%s", files["shop-app.container"])
+	}
+	want := []string{"service app: ports cannot be published with network_mode service:vpn and were dropped"}
+	if !slices.Equal(gen.Warnings(), want) {
+		t.Errorf("Expected only the dropped ports warning, got %v", gen.Warnings())
+	}
+
+	compose.Services["app"] = types.Service{Image: "app", NetworkMode: "service:missing"}
+	if _, err := NewGenerator(compose, t.TempDir()).Render(); err == nil {
+		t.Error("Expected error for network_mode referring to an undefined service")
+	}
+}
+
+func TestGenerateVolumeOptions(t *testing.T) {
+	compose := &types.ComposeFile{
+		Name: "shop",
+		Services: map[string]types.Service{
+			"web": {
+				Image: "nginx",
+				Volumes: []string{
+					"nfs-data:/data:nocopy",
+					"shared:/shared",
+					"legacy:/legacy",
+				},
+			},
+		},
+		Volumes: map[string]types.Volume{
+			"nfs-data": {DriverOpts: map[string]string{
+				"type":   "nfs",
+				"o":      "addr=10.0.0.1,rw",
+				"device": ":/export",
+				"uid":    "1000",
+			}},
+			"shared": {External: true},
+			"legacy": {Name: "legacy-data"},
+		},
+	}
+
+	files, err := NewGenerator(compose, t.TempDir()).Render()
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+
+	if _, ok := files["shared.volume"]; ok {
+		t.Error("External volume should not get a .volume file")
+	}
+	if !strings.Contains(files["legacy-data.volume"], "VolumeName=legacy-data\n") {
+		t.Error("Volume with explicit name should use that name")
+	}
+
+	nfs := files["shop_nfs-data.volume"]
+	for _, line := range []string{"VolumeName=shop_nfs-data", "Type=nfs", "Options=addr=10.0.0.1,rw", "Device=:/export", "User=1000", "Copy=false"} {
+		if !strings.Contains(nfs, line+"\n") {
+			t.Errorf("Expected %q in nfs volume:\n%s", line, nfs)
+		}
+	}
+
+	web := files["shop-web.container"]
+	for _, line := range []string{"Volume=shop_nfs-data.volume:/data:nocopy", "Volume=shared:/shared", "Volume=legacy-data.volume:/legacy"} {
+		if !strings.Contains(web, line+"\n") {
+			t.Errorf("Expected %q in web.container:\n%s", line, web)
+		}
+	}
+}
+
+func TestGenerateEnvFile(t *testing.T) {
+	compose := &types.ComposeFile{
+		Services: map[string]types.Service{
+			"web": {
+				Image:        "nginx",
+				Environment:  map[string]interface{}{"MODE": "prod"},
+				EnvFileVars:  map[string]string{"MODE": "dev", "GREETING": "hello world"},
+				EnvFilePaths: []string{"/srv/app/.env.web"},
+			},
+		},
+	}
+
+	files, err := NewGenerator(compose, t.TempDir()).Render()
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	web := files["web.container"]
+	for _, line := range []string{`Environment="GREETING=hello world"`, "Environment=MODE=prod"} {
+		if !strings.Contains(web, line+"\n") {
+			t.Errorf("Expected %q in inline mode:\n%s", line, web)
+		}
+	}
+
+	gen := NewGeneratorWithOptions(compose, t.TempDir(), Options{EnvFileMode: types.EnvFileReference})
+	files, err = gen.Render()
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	web = files["web.container"]
+	if !strings.Contains(web, "EnvironmentFile=/srv/app/.env.web\n") {
+		t.Errorf("Expected EnvironmentFile= in reference mode:\n%s", web)
+	}
+	if strings.Contains(web, "GREETING") {
+		t.Error("env_file variables should not be inlined in reference mode")
+	}
+
+	// Values Podman would read differently from the file are inlined
+	service := compose.Services["web"]
+	service.EnvFileInline = map[string]string{"QUOTED": "a b", "MODE": "dev"}
+	compose.Services["web"] = service
+	gen = NewGeneratorWithOptions(compose, t.TempDir(), Options{EnvFileMode: types.EnvFileReference})
+	files, err = gen.Render()
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if !strings.Contains(files["web.container"], `Environment="QUOTED=a b"`+"\n") || !strings.Contains(files["web.container"], "Environment=MODE=prod\n") {
+		t.Errorf("Expected QUOTED inline and environment to win for MODE:\n%s", files["web.container"])
+	}
+	if len(gen.Warnings()) != 1 || !strings.Contains(gen.Warnings()[0], "QUOTED") {
+		t.Errorf("Expected a warning for QUOTED, got %v", gen.Warnings())
+	}
+}
+
+func TestGenerateEnvironmentValues(t *testing.T) {
+	compose := &types.ComposeFile{
+		Services: map[string]types.Service{
+			"web": {
+				Image: "nginx",
+				Environment: map[string]interface{}{
+					"PORT":    8080,
+					"DEBUG":   true,
+					"HOME":    nil,
+					"MISSING": nil,
+				},
+			},
+		},
+	}
+	lookup := func(key string) (string, bool) {
+		if key == "HOME" {
+			return "/home/app", true
+		}
+		return "", false
+	}
+
+	gen := NewGeneratorWithOptions(compose, t.TempDir(), Options{LookupEnv: lookup})
+	files, err := gen.Render()
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	web := files["web.container"]
+	for _, line := range []string{"Environment=DEBUG=true", "Environment=HOME=/home/app", "Environment=PORT=8080"} {
+		if !strings.Contains(web, line+"\n") {
+			t.Errorf("Expected %q:\n%s", line, web)
+		}
+	}
+	if strings.Contains(web, "MISSING") {
+		t.Errorf("Unresolved variable should be omitted:\n%s", web)
+	}
+	if len(gen.Warnings()) != 1 || !strings.Contains(gen.Warnings()[0], "MISSING") {
+		t.Errorf("Expected a warning for MISSING, got %v", gen.Warnings())
+	}
+
+	gen = NewGeneratorWithOptions(compose, t.TempDir(), Options{LookupEnv: lookup, EnvPassthrough: true})
+	files, err = gen.Render()
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	web = files["web.container"]
+	for _, line := range []string{"PodmanArgs=--env HOME", "PodmanArgs=--env MISSING"} {
+		if !strings.Contains(web, line+"\n") {
+			t.Errorf("Expected %q in passthrough mode:\n%s", line, web)
+		}
+	}
+	if len(gen.Warnings()) != 0 {
+		t.Errorf("Expected no warnings in passthrough mode, got %v", gen.Warnings())
+	}
+}
+
+func TestGenerateLabelsAndAnnotations(t *testing.T) {
+	compose := &types.ComposeFile{
+		Services: map[string]types.Service{
+			"web": {
+				Image:         "nginx",
+				Labels:        []interface{}{"com.example.description=Web frontend", "com.example.empty"},
+				LabelFileVars: map[string]string{"com.example.description": "from file", "com.example.team": "ops"},
+				Annotations:   map[string]interface{}{"io.podman.annotations.label": "disable"},
+			},
+		},
+	}
+
+	files, err := NewGenerator(compose, t.TempDir()).Render()
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	web := files["web.container"]
+	for _, line := range []string{
+		`Label="com.example.description=Web frontend"`,
+		"Label=com.example.empty=",
+		"Label=com.example.team=ops",
+		"Annotation=io.podman.annotations.label=disable",
+	} {
+		if !strings.Contains(web, line+"\n") {
+			t.Errorf("Expected %q:\n%s", line, web)
+		}
+	}
+}
+
+func TestGenerateSecurity(t *testing.T) {
+	compose := &types.ComposeFile{
+		WorkingDir: "/srv/app",
+		Services: map[string]types.Service{
+			"web": {
+				Image:       "nginx",
+				ReadOnly:    true,
+				UsernsMode:  "keep-id",
+				GroupAdd:    []string{"wheel", "1000"},
+				SecurityOpt: []string{"no-new-privileges:true", "seccomp:seccomp.json", "label:type:spc_t", "apparmor=unconfined"},
+			},
+		},
+	}
+
+	files, err := NewGenerator(compose, t.TempDir()).Render()
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	web := files["web.container"]
+	for _, line := range []string{
+		"ReadOnly=true",
+		"NoNewPrivileges=true",
+		"SeccompProfile=/srv/app/seccomp.json",
+		"SecurityLabelType=spc_t",
+		"PodmanArgs=--security-opt apparmor=unconfined",
+		"UserNS=keep-id",
+		"GroupAdd=wheel",
+		"GroupAdd=1000",
+	} {
+		if !strings.Contains(web, line+"\n") {
+			t.Errorf("Expected %q:\n%s", line, web)
+		}
+	}
+}
+
+func TestGeneratePrivileges(t *testing.T) {
+	compose := &types.ComposeFile{
+		Services: map[string]types.Service{
+			"web": {
+				Image:      "nginx",
+				Privileged: true,
+				CapAdd:     []string{"net_admin"},
+				CapDrop:    []string{"ALL"},
+			},
+		},
+	}
+
+	files, err := NewGenerator(compose, t.TempDir()).Render()
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	web := files["web.container"]
+	for _, line := range []string{"PodmanArgs=--privileged", "AddCapability=CAP_NET_ADMIN", "DropCapability=ALL"} {
+		if !strings.Contains(web, line+"\n") {
+			t.Errorf("Expected %q:\n%s", line, web)
+		}
+	}
+}
+
+func TestGenerateDevices(t *testing.T) {
+	compose := &types.ComposeFile{
+		Services: map[string]types.Service{
+			"web": {
+				Image:             "nginx",
+				Devices:           []interface{}{"/dev/ttyUSB0", "/dev/sda:/dev/xvda:r"},
+				DeviceCgroupRules: []string{"c 188:* rmw"},
+				Deploy: &types.Deploy{Resources: types.Resources{Reservations: types.ResourceSpec{
+					Devices: []types.DeviceRequest{{Driver: "nvidia", Count: "all", Capabilities: []string{"gpu"}}},
+				}}},
+			},
+		},
+	}
+
+	files, err := NewGenerator(compose, t.TempDir()).Render()
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	web := files["web.container"]
+	for _, line := range []string{
+		"AddDevice=/dev/ttyUSB0",
+		"AddDevice=/dev/sda:/dev/xvda:r",
+		"AddDevice=nvidia.com/gpu=all",
+		`PodmanArgs=--device-cgroup-rule "c 188:* rmw"`,
+	} {
+		if !strings.Contains(web, line+"\n") {
+			t.Errorf("Expected %q:\n%s", line, web)
+		}
+	}
+}
+
+func TestGenerateResources(t *testing.T) {
+	compose := &types.ComposeFile{
+		Services: map[string]types.Service{
+			"web": {
+				Image:   "nginx",
+				Tmpfs:   []interface{}{"/run", "/tmp:size=64m,mode=1777"},
+				ShmSize: "256m",
+				Ulimits: map[string]interface{}{"nofile": map[string]interface{}{"soft": 1024, "hard": 2048}},
+				Sysctls: []interface{}{"net.core.somaxconn=1024"},
+			},
+		},
+	}
+
+	files, err := NewGenerator(compose, t.TempDir()).Render()
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	web := files["web.container"]
+	for _, line := range []string{
+		"Tmpfs=/run",
+		"Tmpfs=/tmp:size=64m,mode=1777",
+		"ShmSize=256m",
+		"Ulimit=nofile=1024:2048",
+		"Sysctl=net.core.somaxconn=1024",
+	} {
+		if !strings.Contains(web, line+"\n") {
+			t.Errorf("Expected %q:\n%s", line, web)
+		}
+	}
+
+	compose.Services["web"] = types.Service{Image: "nginx", ShmSize: "big"}
+	if _, err := NewGenerator(compose, t.TempDir()).Render(); err == nil {
+		t.Error("Expected error for an invalid shm_size")
+	}
+}
+
+func TestGenerateDNS(t *testing.T) {
+	compose := &types.ComposeFile{
+		Services: map[string]types.Service{
+			"web": {
+				Image:      "nginx",
+				Domainname: "example.com",
+				DNS:        "10.0.0.53",
+				DNSSearch:  []interface{}{"corp.example.com"},
+				DNSOpt:     []string{"ndots:2"},
+				ExtraHosts: []interface{}{"db=10.0.0.5", "host.docker.internal:host-gateway"},
+			},
+		},
+	}
+
+	gen := NewGenerator(compose, t.TempDir())
+	files, err := gen.Render()
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	web := files["web.container"]
+	for _, line := range []string{
+		"HostName=web.example.com",
+		"DNS=10.0.0.53",
+		"DNSSearch=corp.example.com",
+		"DNSOption=ndots:2",
+		"AddHost=db:10.0.0.5",
+		"AddHost=host.docker.internal:host-gateway",
+	} {
+		if !strings.Contains(web, line+"\n") {
+			t.Errorf("Expected %q:\n%s", line, web)
+		}
+	}
+	if len(gen.Warnings()) != 1 {
+		t.Errorf("Expected a warning for domainname, got %v", gen.Warnings())
+	}
+}
+
+func TestGenerateRestart(t *testing.T) {
+	tests := []struct {
+		name     string
+		service  types.Service
+		expected []string
+		absent   []string
+	}{
+		{
+			name:     "default is no",
+			service:  types.Service{Image: "nginx"},
+			expected: []string{"Restart=no"},
+			absent:   []string{"StartLimitBurst="},
+		},
+		{
+			name:     "on-failure with count",
+			service:  types.Service{Image: "nginx", Restart: "on-failure:5"},
+			expected: []string{"Restart=on-failure", "StartLimitBurst=6", "StartLimitIntervalSec=infinity"},
+		},
+		{
+			name: "deploy restart_policy",
+			service: types.Service{Image: "nginx", Deploy: &types.Deploy{RestartPolicy: &types.RestartPolicy{
+				Condition: "any", Delay: "1500ms", MaxAttempts: 3, Window: "2m",
+			}}},
+			expected: []string{"Restart=always", "RestartSec=1.5", "StartLimitBurst=4", "StartLimitIntervalSec=120"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compose := &types.ComposeFile{Services: map[string]types.Service{"web": tt.service}}
+			files, err := NewGenerator(compose, t.TempDir()).Render()
+			if err != nil {
+				t.Fatalf("Render failed: %v", err)
+			}
+			web := files["web.container"]
+			for _, line := range tt.expected {
+				if !strings.Contains(web, line+"\n") {
+					t.Errorf("Expected %q:\n%s", line, web)
+				}
+			}
+			for _, line := range tt.absent {
+				if strings.Contains(web, line) {
+					t.Errorf("Unexpected %q:\n%s", line, web)
+				}
+			}
+		})
+	}
+
+	compose := &types.ComposeFile{Services: map[string]types.Service{"web": {Image: "nginx", Restart: "sometimes"}}}
+	if _, err := NewGenerator(compose, t.TempDir()).Render(); err == nil {
+		t.Error("Expected error for an invalid restart policy")
+	}
+}
+
+func TestGenerateStop(t *testing.T) {
+	runInit := true
+	compose := &types.ComposeFile{
+		Services: map[string]types.Service{
+			"web": {
+				Image:           "nginx",
+				StopSignal:      "SIGQUIT",
+				StopGracePeriod: "1m30s",
+				Init:            &runInit,
+			},
+		},
+	}
+
+	files, err := NewGenerator(compose, t.TempDir()).Render()
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	web := files["web.container"]
+	for _, line := range []string{"StopSignal=SIGQUIT", "StopTimeout=90", "RunInit=true", "TimeoutStopSec=100"} {
+		if !strings.Contains(web, line+"\n") {
+			t.Errorf("Expected %q:\n%s", line, web)
+		}
+	}
+
+	compose.Services["web"] = types.Service{Image: "nginx", StopGracePeriod: "forever"}
+	if _, err := NewGenerator(compose, t.TempDir()).Render(); err == nil {
+		t.Error("Expected error for an invalid stop_grace_period")
+	}
+}
+
+func TestGenerateHooks(t *testing.T) {
+	compose := &types.ComposeFile{
+		Services: map[string]types.Service{
+			"web": {
+				Image: "nginx",
+				PostStart: []types.Hook{{
+					Command:     []interface{}{"/app/migrate.sh", "--to", "latest"},
+					User:        "root",
+					Environment: map[string]interface{}{"MODE": "post start"},
+				}},
+				PreStop: []types.Hook{{Command: `nginx -s quit "$HOME/a b"`}},
+			},
+		},
+	}
+
+	files, err := NewGenerator(compose, t.TempDir()).Render()
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	web := files["web.container"]
+	for _, line := range []string{
+		`ExecStartPost=podman exec --user root --env "MODE=post start" web /app/migrate.sh --to latest`,
+		`ExecStop=-podman exec web nginx -s quit "$$HOME/a b"`,
+	} {
+		if !strings.Contains(web, line+"\n") {
+			t.Errorf("Expected %q:\n%s", line, web)
+		}
+	}
+	if strings.Index(web, "ExecStartPost=") < strings.Index(web, "[Service]") {
+		t.Error("Hooks belong to the [Service] section")
+	}
+}
+
+func TestGenerateNamespaces(t *testing.T) {
+	compose := &types.ComposeFile{
+		Name: "shop",
+		Services: map[string]types.Service{
+			"app": {Image: "app", Ipc: "shareable"},
+			"debug": {
+				Image:        "busybox",
+				Tty:          true,
+				StdinOpen:    true,
+				Pid:          "service:app",
+				Ipc:          "service:app",
+				Cgroup:       "host",
+				CgroupParent: "machine.slice",
+			},
+		},
+	}
+
+	files, err := NewGenerator(compose, t.TempDir()).Render()
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	debug := files["shop-debug.container"]
+	for _, line := range []string{
+		"After=shop-app.service",
+		"PodmanArgs=--tty",
+		"PodmanArgs=--interactive",
+		"PodmanArgs=--pid container:shop-app-1",
+		"PodmanArgs=--ipc container:shop-app-1",
+		"PodmanArgs=--cgroupns host",
+		"CgroupsMode=enabled",
+		"PodmanArgs=--cgroup-parent machine.slice",
+	} {
+		if !strings.Contains(debug, line+"\n") {
+			t.Errorf("Expected %q:\n%s", line, debug)
+		}
+	}
+	if !strings.Contains(files["shop-app.container"], "PodmanArgs=--ipc shareable\n") {
+		t.Errorf("Expected shareable IPC:\n%s", files["shop-app.container"])
+	}
+
+	compose.Services["debug"] = types.Service{Image: "busybox", Pid: "service:missing"}
+	if _, err := NewGenerator(compose, t.TempDir()).Render(); err == nil {
+		t.Error("Expected error for pid referring to an undefined service")
+	}
+}
+
+func TestGenerateLogging(t *testing.T) {
+	compose := &types.ComposeFile{
+		Services: map[string]types.Service{
+			"web": {
+				Image:   "nginx",
+				Logging: &types.Logging{Driver: "json-file", Options: map[string]string{"max-size": "10m", "max-file": "3"}},
+			},
+		},
+	}
+
+	gen := NewGenerator(compose, t.TempDir())
+	files, err := gen.Render()
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	web := files["web.container"]
+	for _, line := range []string{"LogDriver=k8s-file", "LogOpt=max-size=10m"} {
+		if !strings.Contains(web, line+"\n") {
+			t.Errorf("Expected %q:\n%s", line, web)
+		}
+	}
+	if len(gen.Warnings()) != 1 || !strings.Contains(gen.Warnings()[0], "max-file") {
+		t.Errorf("Expected a warning for max-file, got %v", gen.Warnings())
+	}
+}
+
+func TestGenerateImagePolicy(t *testing.T) {
+	compose := &types.ComposeFile{
+		Services: map[string]types.Service{
+			"web": {Image: "docker.io/library/nginx:latest", PullPolicy: "always", Platform: "linux/arm64"},
+			"api": {Image: "node", PullPolicy: "daily", XPodman: &types.XPodman{AutoUpdate: "local"}},
+			"db":  {Image: "docker.io/library/postgres", XPodman: &types.XPodman{AutoUpdate: "disabled"}},
+		},
+	}
+
+	gen := NewGeneratorWithOptions(compose, t.TempDir(), Options{AutoUpdate: types.AutoUpdateRegistry})
+	files, err := gen.Render()
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+
+	for file, lines := range map[string][]string{
+		"web.container": {"Pull=always", "PodmanArgs=--platform linux/arm64", "AutoUpdate=registry"},
+		"api.container": {"Pull=newer", "AutoUpdate=local"},
+	} {
+		for _, line := range lines {
+			if !strings.Contains(files[file], line+"\n") {
+				t.Errorf("Expected %q in %s:\n%s", line, file, files[file])
+			}
+		}
+	}
+	if strings.Contains(files["db.container"], "AutoUpdate=") {
+		t.Errorf("Service opted out of auto-update:\n%s", files["db.container"])
+	}
+	if len(gen.Warnings()) != 1 || !strings.Contains(gen.Warnings()[0], "daily") {
+		t.Errorf("Expected a warning for pull_policy daily, got %v", gen.Warnings())
+	}
+}
+
+func TestGenerateImageUnits(t *testing.T) {
+	tlsVerify := false
+	compose := &types.ComposeFile{
+		Name: "shop",
+		Services: map[string]types.Service{
+			"api":    {Image: "docker.io/library/node:20", PullPolicy: "always", Platform: "linux/arm64/v8"},
+			"worker": {Image: "docker.io/library/node:20"},
+			"db":     {Image: "postgres"},
+		},
+	}
+
+	gen := NewGeneratorWithOptions(compose, t.TempDir(), Options{
+		ImageUnits: true,
+		AuthFile:   "/run/containers/auth.json",
+		TLSVerify:  &tlsVerify,
+	})
+	files, err := gen.Render()
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+
+	node := files["shop-docker.io-library-node-20.image"]
+	for _, line := range []string{
+		"Image=docker.io/library/node:20",
+		"Policy=always",
+		"OS=linux",
+		"Arch=arm64",
+		"Variant=v8",
+		"AuthFile=/run/containers/auth.json",
+		"TLSVerify=false",
+	} {
+		if !strings.Contains(node, line+"\n") {
+			t.Errorf("Expected %q in image unit:\n%s", line, node)
+		}
+	}
+	if _, ok := files["shop-postgres.image"]; !ok {
+		t.Errorf("Expected an image unit for postgres, got %v", sortedKeys(files))
+	}
+
+	for file, image := range map[string]string{
+		"shop-api.container":    "shop-docker.io-library-node-20.image",
+		"shop-worker.container": "shop-docker.io-library-node-20.image",
+		"shop-db.container":     "shop-postgres.image",
+	} {
+		if !strings.Contains(files[file], "Image="+image+"\n") {
+			t.Errorf("Expected %s to use %s:\n%s", file, image, files[file])
+		}
+		if strings.Contains(files[file], "Pull=") || strings.Contains(files[file], "--platform") {
+			t.Errorf("Pull policy and platform belong in the image unit:\n%s", files[file])
+		}
+	}
+
+	if len(gen.Warnings()) != 1 || !strings.Contains(gen.Warnings()[0], "shared with service api") {
+		t.Errorf("Expected a warning for the differing worker settings, got %v", gen.Warnings())
+	}
+	if issues := Validate(files); len(issues) != 0 {
+		t.Errorf("Unexpected validation issues: %v", issues)
+	}
+}
+
+func TestGenerateImageUnitsCollision(t *testing.T) {
+	compose := &types.ComposeFile{
+		Services: map[string]types.Service{
+			"web": {Image: "nginx:1.25"},
+			"api": {Image: "nginx-1.25"},
+		},
+	}
+
+	gen := NewGeneratorWithOptions(compose, t.TempDir(), Options{ImageUnits: true})
+	_, err := gen.Render()
+	if err == nil || !strings.Contains(err.Error(), "nginx-1.25.image") {
+		t.Errorf("Expected an error for colliding image unit names, got %v", err)
+	}
+}
+
+func TestGenerateImageUnitsRefreshWarning(t *testing.T) {
+	compose := &types.ComposeFile{
+		Services: map[string]types.Service{
+			"api":    {Image: "docker.io/library/node:20", PullPolicy: "daily"},
+			"worker": {Image: "docker.io/library/node:20", PullPolicy: "daily"},
+		},
+	}
+
+	gen := NewGeneratorWithOptions(compose, t.TempDir(), Options{ImageUnits: true})
+	files, err := gen.Render()
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if !strings.Contains(files["docker.io-library-node-20.image"], "Policy=newer\n") {
+		t.Errorf("Expected Policy=newer in the image unit:\n%s", files["docker.io-library-node-20.image"])
+	}
+	if len(gen.Warnings()) != 1 || !strings.Contains(gen.Warnings()[0], "using Policy=newer") {
+		t.Errorf("Expected a single warning naming Policy=newer, got %v", gen.Warnings())
+	}
+}
+
+func TestGenerateKube(t *testing.T) {
+	compose := &types.ComposeFile{
+		Name: "shop",
+		Services: map[string]types.Service{
+			"web": {
+				Image:       "docker.io/library/nginx",
+				Ports:       []string{"127.0.0.1:8080:80"},
+				Networks:    []interface{}{"front"},
+				EnvFileVars: map[string]string{"MODE": "prod"},
+				XPodman:     &types.XPodman{AutoUpdate: "local"},
+			},
+			"db": {Image: "docker.io/library/postgres", Networks: []interface{}{"back", "front"}},
+		},
+		Networks: map[string]types.Network{
+			"front": {},
+			"back":  {External: true},
+		},
+		Volumes: map[string]types.Volume{"data": {}},
+		XPodman: &types.XPodman{Quadlet: map[string]map[string]interface{}{"Kube": {"KubeDownForce": true}}},
+	}
+
+	gen := NewGeneratorWithOptions(compose, t.TempDir(), Options{
+		Kube:                true,
+		EnvFileMode:         types.EnvFileReference,
+		AutoUpdate:          types.AutoUpdateRegistry,
+		ExitCodePropagation: ExitCodeAny,
+	})
+	files, err := gen.Render()
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+
+	expectedFiles := []string{"shop-configmaps.yaml", "shop.kube", "shop.yaml", "shop_front.network"}
+	if names := sortedKeys(files); !slices.Equal(names, expectedFiles) {
+		t.Fatalf("Expected files %v, got %v", expectedFiles, names)
+	}
+
+	expected := `[Kube]
+Yaml=shop.yaml
+ConfigMap=shop-configmaps.yaml
+PublishPort=127.0.0.1:8080:80
+Network=back
+Network=shop_front.network
+AutoUpdate=db/registry
+AutoUpdate=web/local
+ExitCodePropagation=any
+KubeDownForce=true
+`
+	if !strings.Contains(files["shop.kube"], expected) {
+		t.Errorf("Expected [Kube] section:\n%s\ngot:\n%s", expected, files["shop.kube"])
+	}
+
+	if !strings.HasPrefix(files["shop.yaml"], ManagedHeader+"\n") || !strings.Contains(files["shop.yaml"], "kind: Pod\n") {
+		t.Errorf("Expected the managed pod YAML, got:\n%s", files["shop.yaml"])
+	}
+	if strings.Contains(files["shop.yaml"], "kind: ConfigMap") {
+		t.Errorf("ConfigMaps belong in their own file:\n%s", files["shop.yaml"])
+	}
+	if !strings.Contains(files["shop-configmaps.yaml"], "  MODE: \"prod\"\n") {
+		t.Errorf("Expected env_file variables in the ConfigMap:\n%s", files["shop-configmaps.yaml"])
+	}
+	if issues := Validate(files); len(issues) != 0 {
+		t.Errorf("Unexpected validation issues: %v", issues)
+	}
+}
+
+func TestGenerateKubeOptions(t *testing.T) {
+	compose := &types.ComposeFile{
+		Services: map[string]types.Service{
+			"web": {Image: "docker.io/library/nginx"},
+			"db":  {Image: "docker.io/library/postgres"},
+		},
+	}
+
+	gen := NewGeneratorWithOptions(compose, t.TempDir(), Options{Kube: true, PodName: "app", AutoUpdate: types.AutoUpdateRegistry})
+	files, err := gen.Render()
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if strings.Count(files["app.kube"], "AutoUpdate=") != 1 || !strings.Contains(files["app.kube"], "AutoUpdate=registry\n") {
+		t.Errorf("Expected a single AutoUpdate=registry:\n%s", files["app.kube"])
+	}
+
+	gen = NewGeneratorWithOptions(compose, t.TempDir(), Options{Kube: true, ImageUnits: true, AuthFile: "/run/auth.json"})
+	files, err = gen.Render()
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	for name := range files {
+		if strings.HasSuffix(name, ".image") {
+			t.Errorf("Unexpected image unit %s for a kube pod", name)
+		}
+	}
+	if len(gen.Warnings()) != 1 || !strings.Contains(gen.Warnings()[0], "image units") {
+		t.Errorf("Expected a warning for the ignored image unit options, got %v", gen.Warnings())
+	}
+
+	gen = NewGeneratorWithOptions(compose, t.TempDir(), Options{Kube: true, ExitCodePropagation: "some"})
+	if _, err := gen.Render(); err == nil {
+		t.Error("Expected an error for an unknown exit code propagation")
+	}
+}
+
+func TestGenerateXPodman(t *testing.T) {
+	compose := &types.ComposeFile{
+		XPodman: &types.XPodman{Quadlet: map[string]map[string]interface{}{
+			"Network": {"DisableDNS": true},
+			"Volume":  {"Copy": false},
+		}},
+		Services: map[string]types.Service{
+			"web": {
+				Image:      "nginx",
+				UsernsMode: "host",
+				Volumes:    []string{"./html:/usr/share/nginx/html", "./conf:/etc/nginx:ro", "./certs:/certs:ro,Z", "data:/data"},
+				XPodman: &types.XPodman{
+					UserNS:      "keep-id",
+					Pod:         "shop",
+					PodmanArgs:  []string{"--pids-limit=100"},
+					GlobalArgs:  []string{"--log-level=debug"},
+					Notify:      "healthy",
+					Relabel:     "z",
+					PortHandler: "slirp4netns",
+					Quadlet: map[string]map[string]interface{}{
+						"Unit":      {"Wants": "network-online.target"},
+						"Container": {"Mask": []interface{}{"/proc/a", "/proc/b"}},
+						"Service":   {"Restart": "always"},
+						"Install":   {"WantedBy": "multi-user.target"},
+					},
+				},
+			},
+		},
+		Networks: map[string]types.Network{"front": {}},
+		Volumes:  map[string]types.Volume{"data": {}},
+	}
+
+	gen := NewGenerator(compose, t.TempDir())
+	files, err := gen.Render()
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+
+	web := files["web.container"]
+	for _, line := range []string{
+		"Wants=network-online.target\n\n[Container]",
+		"UserNS=keep-id\n",
+		"Pod=shop.pod\n",
+		"PodmanArgs=--pids-limit=100\n",
+		"GlobalArgs=--log-level=debug\n",
+		"Notify=healthy\n",
+		"Volume=./html:/usr/share/nginx/html:z\n",
+		"Volume=./conf:/etc/nginx:ro,z\n",
+		"Volume=./certs:/certs:ro,Z\n",
+		"Volume=data.volume:/data\n",
+		"Network=slirp4netns:port_handler=slirp4netns\n",
+		"Mask=/proc/a\nMask=/proc/b\n\n[Service]",
+		"Restart=always\n\n[Install]",
+		"WantedBy=default.target\nWantedBy=multi-user.target\n",
+	} {
+		if !strings.Contains(web, line) {
+			t.Errorf("Expected %q in web.container:\n%s", line, web)
+		}
+	}
+	if strings.Contains(web, "UserNS=host") {
+		t.Errorf("x-podman.userns should override userns_mode:\n%s", web)
+	}
+	if len(gen.Warnings()) != 1 || !strings.Contains(gen.Warnings()[0], "moves the container") {
+		t.Errorf("Expected a warning for the implicit slirp4netns network, got %v", gen.Warnings())
+	}
+	if !strings.Contains(files["front.network"], "DisableDNS=true\n\n[Install]") {
+		t.Errorf("Expected top-level Network keys:\n%s", files["front.network"])
+	}
+	if !strings.Contains(files["data.volume"], "Copy=false\n\n[Install]") {
+		t.Errorf("Expected top-level Volume keys:\n%s", files["data.volume"])
+	}
+}
+
+func TestGenerateXPodmanPortHandler(t *testing.T) {
+	compose := &types.ComposeFile{
+		Services: map[string]types.Service{
+			"web": {Image: "nginx", NetworkMode: "pasta", XPodman: &types.XPodman{PortHandler: "rootlessport"}},
+			"api": {Image: "node", NetworkMode: "slirp4netns", XPodman: &types.XPodman{PortHandler: "rootlessport"}},
+		},
+	}
+
+	gen := NewGenerator(compose, t.TempDir())
+	files, err := gen.Render()
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if !strings.Contains(files["web.container"], "Network=pasta\n") {
+		t.Errorf("Expected the network mode to be kept:\n%s", files["web.container"])
+	}
+	if !strings.Contains(files["api.container"], "Network=slirp4netns:port_handler=rootlessport\n") {
+		t.Errorf("Expected the port handler on the explicit slirp4netns network:\n%s", files["api.container"])
+	}
+	if len(gen.Warnings()) != 1 || !strings.Contains(gen.Warnings()[0], "port-handler") {
+		t.Errorf("Expected a port-handler warning, got %v", gen.Warnings())
+	}
+}
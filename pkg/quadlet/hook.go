@@ -0,0 +1,72 @@
+package quadlet
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kad/compose2podman/internal/types"
+)
+
+// podmanPath is the podman binary used in Exec lines of the [Service]
+// section. systemd resolves a bare name from its executable search path, so
+// podman need not be installed in /usr/bin.
+const podmanPath = "podman"
+
+// writeHooks writes post_start hooks as ExecStartPost= and pre_stop hooks as
+// ExecStop= running podman exec in the container. Quadlet appends its own
+// ExecStop= lines, so pre_stop hooks run before the container is stopped;
+// as in Compose, a failing pre_stop hook does not prevent stopping.
+func writeHooks(sb *strings.Builder, name, containerName string, service types.Service) error {
+	for _, hook := range service.PostStart {
+		command, err := hookCommand(containerName, hook)
+		if err != nil {
+			return fmt.Errorf("service %s: post_start %w", name, err)
+		}
+		sb.WriteString(fmt.Sprintf("ExecStartPost=%s\n", command))
+	}
+	for _, hook := range service.PreStop {
+		command, err := hookCommand(containerName, hook)
+		if err != nil {
+			return fmt.Errorf("service %s: pre_stop %w", name, err)
+		}
+		sb.WriteString(fmt.Sprintf("ExecStop=-%s\n", command))
+	}
+	return nil
+}
+
+// hookCommand returns the podman exec command line of a hook
+func hookCommand(containerName string, hook types.Hook) (string, error) {
+	command, err := hook.CommandList()
+	if err != nil {
+		return "", err
+	}
+
+	args := []string{podmanPath, "exec"}
+	if hook.User != "" {
+		args = append(args, "--user", hook.User)
+	}
+	if hook.Privileged {
+		args = append(args, "--privileged")
+	}
+	if hook.WorkingDir != "" {
+		args = append(args, "--workdir", hook.WorkingDir)
+	}
+	for _, env := range hook.EnvironmentList() {
+		args = append(args, "--env", env)
+	}
+	args = append(args, containerName)
+	args = append(args, command...)
+
+	words := make([]string, 0, len(args))
+	for _, arg := range args {
+		words = append(words, execWord(arg))
+	}
+	return strings.Join(words, " "), nil
+}
+
+// execWord quotes a word of a systemd command line. Besides the quoting of
+// quoteWord, dollar signs are doubled since systemd expands $VAR in Exec
+// lines.
+func execWord(word string) string {
+	return quoteWord(strings.ReplaceAll(word, "$", "$$"))
+}
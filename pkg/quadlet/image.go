@@ -0,0 +1,208 @@
+package quadlet
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/kad/compose2podman/internal/types"
+)
+
+// quadletPull maps pull policies to Quadlet Pull= and Policy= values
+var quadletPull = map[string]string{
+	types.PullAlways:  "always",
+	types.PullNever:   "never",
+	types.PullMissing: "missing",
+}
+
+// imageUnit holds the settings of a .image unit shared by all services
+// using the same image. The first service in name order defines them.
+type imageUnit struct {
+	service  string
+	pull     string
+	platform string
+}
+
+// writeImage writes the image with its pull_policy, platform and auto-update
+// policy. With ImageUnits the image refers to its .image unit, which carries
+// the pull policy and platform instead.
+func (g *Generator) writeImage(sb *strings.Builder, name string, service types.Service) error {
+	switch {
+	case service.Image == "":
+	case g.opts.ImageUnits:
+		sb.WriteString(fmt.Sprintf("Image=%s\n", g.imageFile(service.Image)))
+	default:
+		sb.WriteString(fmt.Sprintf("Image=%s\n", service.Image))
+
+		pull, err := g.pullPolicy(name, service, "Pull")
+		if err != nil {
+			return err
+		}
+		if pull != "" {
+			sb.WriteString(fmt.Sprintf("Pull=%s\n", pull))
+		}
+		if service.Platform != "" {
+			sb.WriteString(fmt.Sprintf("PodmanArgs=--platform %s\n", service.Platform))
+		}
+	}
+
+	policy, err := service.AutoUpdatePolicy(g.opts.AutoUpdate)
+	if err != nil {
+		return fmt.Errorf("service %s: %w", name, err)
+	}
+	if policy != "" {
+		if policy == types.AutoUpdateRegistry && !types.IsFullyQualifiedImage(service.Image) {
+			g.warnf("service %s: auto-update registry needs a fully qualified image name, not %s", name, service.Image)
+		}
+		sb.WriteString(fmt.Sprintf("AutoUpdate=%s\n", policy))
+	}
+	return nil
+}
+
+// pullPolicy returns the Quadlet pull policy of a service, or "" for the
+// Podman default. Policies Podman lacks get a warning naming key, the Pull=
+// of a container or the Policy= of an image unit the value is written to.
+func (g *Generator) pullPolicy(name string, service types.Service, key string) (string, error) {
+	pull, err := service.ParsePullPolicy()
+	if err != nil {
+		return "", fmt.Errorf("service %s: %w", name, err)
+	}
+	switch {
+	case pull.Policy == types.PullBuild:
+		g.warnf("service %s: pull_policy build is not supported (build is not supported); using the default", name)
+	case pull.Refresh:
+		g.warnf("service %s: pull_policy %s has no Podman equivalent; using %s=newer", name, service.PullPolicy, key)
+	}
+	return quadletPullValue(pull), nil
+}
+
+// quadletPullValue returns the Quadlet value of a parsed pull policy.
+// Podman cannot pull on a schedule; newer pulls when the registry has a
+// newer image, which is what a refresh interval approximates.
+func quadletPullValue(pull types.PullPolicy) string {
+	if pull.Refresh {
+		return "newer"
+	}
+	return quadletPull[pull.Policy]
+}
+
+// imageFile returns the unit file name of an image, <project>-<image>.image,
+// with every character systemd does not allow in unit names replaced by -
+func (g *Generator) imageFile(image string) string {
+	name := strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '.' || r == '_' || r == '-') {
+			return r
+		}
+		return '-'
+	}, image)
+	if g.compose.Name == "" {
+		return name + ".image"
+	}
+	return fmt.Sprintf("%s-%s.image", g.compose.Name, name)
+}
+
+// generateImages returns a .image unit for every distinct image of the
+// services, keyed by file name. Services sharing an image with a different
+// pull_policy or platform get a warning. Images whose names map to the same
+// file name are an error.
+func (g *Generator) generateImages() (map[string]string, error) {
+	units := make(map[string]*imageUnit)
+	var images []string
+	for _, name := range sortedKeys(g.compose.Services) {
+		service := g.compose.Services[name]
+		if service.Image == "" {
+			continue
+		}
+
+		unit, ok := units[service.Image]
+		if !ok {
+			// The first service defines the unit and gets the pull_policy warnings
+			pull, err := g.pullPolicy(name, service, "Policy")
+			if err != nil {
+				return nil, err
+			}
+			units[service.Image] = &imageUnit{service: name, pull: pull, platform: service.Platform}
+			images = append(images, service.Image)
+			continue
+		}
+		parsed, err := service.ParsePullPolicy()
+		if err != nil {
+			return nil, fmt.Errorf("service %s: %w", name, err)
+		}
+		if unit.pull != quadletPullValue(parsed) || unit.platform != service.Platform {
+			g.warnf("service %s: image %s is shared with service %s, whose pull_policy and platform are used", name, service.Image, unit.service)
+		}
+	}
+
+	files := make(map[string]string, len(images))
+	owners := make(map[string]string, len(images))
+	for _, image := range images {
+		file := g.imageFile(image)
+		if other, ok := owners[file]; ok {
+			return nil, fmt.Errorf("images %s and %s both map to the unit file %s", other, image, file)
+		}
+		owners[file] = image
+
+		content, err := g.generateImage(image, units[image])
+		if err != nil {
+			return nil, err
+		}
+		files[file] = content
+	}
+	return files, nil
+}
+
+func (g *Generator) generateImage(image string, unit *imageUnit) (string, error) {
+	var sb strings.Builder
+
+	sb.WriteString(g.header())
+
+	sb.WriteString("[Unit]\n")
+	sb.WriteString(fmt.Sprintf("Description=%s image\n", image))
+
+	sb.WriteString("\n[Image]\n")
+	sb.WriteString(fmt.Sprintf("Image=%s\n", image))
+
+	if unit.pull != "" {
+		sb.WriteString(fmt.Sprintf("Policy=%s\n", unit.pull))
+	}
+
+	if unit.platform != "" {
+		os, arch, variant, err := splitPlatform(unit.platform)
+		if err != nil {
+			return "", fmt.Errorf("service %s: %w", unit.service, err)
+		}
+		sb.WriteString(fmt.Sprintf("OS=%s\n", os))
+		sb.WriteString(fmt.Sprintf("Arch=%s\n", arch))
+		if variant != "" {
+			sb.WriteString(fmt.Sprintf("Variant=%s\n", variant))
+		}
+	}
+
+	if g.opts.AuthFile != "" {
+		sb.WriteString(fmt.Sprintf("AuthFile=%s\n", quoteWord(g.opts.AuthFile)))
+	}
+	if g.opts.TLSVerify != nil {
+		sb.WriteString(fmt.Sprintf("TLSVerify=%t\n", *g.opts.TLSVerify))
+	}
+	writeRaw(&sb, g.compose.XPodman, "Image")
+
+	sb.WriteString("\n[Install]\n")
+	sb.WriteString("WantedBy=default.target\n")
+
+	return sb.String(), nil
+}
+
+// splitPlatform splits a platform like linux/arm64/v8 into its OS,
+// architecture and optional variant
+func splitPlatform(platform string) (os, arch, variant string, err error) {
+	parts := strings.Split(platform, "/")
+	if len(parts) < 2 || len(parts) > 3 || slices.Contains(parts, "") {
+		return "", "", "", fmt.Errorf("invalid platform %q: expected os/arch[/variant]", platform)
+	}
+	if len(parts) == 3 {
+		variant = parts[2]
+	}
+	return parts[0], parts[1], variant, nil
+}
@@ -0,0 +1,122 @@
+package quadlet
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Scope selects whether units are installed for the current user (rootless)
+// or system-wide (rootful)
+type Scope int
+
+// Installation scopes
+const (
+	ScopeUser Scope = iota
+	ScopeSystem
+)
+
+// SystemUnitDir is the Quadlet search directory for rootful units
+const SystemUnitDir = "/etc/containers/systemd"
+
+// Runner executes external commands and returns their combined output.
+// It is an interface so tests can substitute a fake systemctl.
+type Runner interface {
+	Run(name string, args ...string) ([]byte, error)
+}
+
+// ExecRunner runs commands on the host using os/exec
+type ExecRunner struct{}
+
+// Run executes name with args
+func (ExecRunner) Run(name string, args ...string) ([]byte, error) {
+	return exec.Command(name, args...).CombinedOutput()
+}
+
+// UnitDir returns the directory Quadlet reads units from for scope.
+// Rootless units honor XDG_CONFIG_HOME and fall back to ~/.config.
+func UnitDir(scope Scope, getenv func(string) string) (string, error) {
+	if scope == ScopeSystem {
+		return SystemUnitDir, nil
+	}
+	if config := getenv("XDG_CONFIG_HOME"); config != "" {
+		return filepath.Join(config, "containers", "systemd"), nil
+	}
+	home := getenv("HOME")
+	if home == "" {
+		return "", errors.New("cannot determine user unit directory: neither XDG_CONFIG_HOME nor HOME is set")
+	}
+	return filepath.Join(home, ".config", "containers", "systemd"), nil
+}
+
+// Installer reloads systemd and starts units after Quadlet files have been
+// written to the unit directory
+type Installer struct {
+	Scope  Scope
+	Runner Runner
+}
+
+// NewInstaller creates an installer that runs systemctl on the host
+func NewInstaller(scope Scope) *Installer {
+	return &Installer{Scope: scope, Runner: ExecRunner{}}
+}
+
+// Reload runs systemctl daemon-reload so the Quadlet generator picks up new units
+func (i *Installer) Reload() error {
+	return i.systemctl("daemon-reload")
+}
+
+// Start starts the given systemd units
+func (i *Installer) Start(units []string) error {
+	if len(units) == 0 {
+		return nil
+	}
+	return i.systemctl(append([]string{"start"}, units...)...)
+}
+
+func (i *Installer) systemctl(args ...string) error {
+	if i.Scope == ScopeUser {
+		args = append([]string{"--user"}, args...)
+	}
+	out, err := i.Runner.Run("systemctl", args...)
+	if err != nil {
+		msg := strings.TrimSpace(string(out))
+		if msg == "" {
+			return fmt.Errorf("systemctl %s failed: %w", strings.Join(args, " "), err)
+		}
+		return fmt.Errorf("systemctl %s failed: %w: %s", strings.Join(args, " "), err, msg)
+	}
+	return nil
+}
+
+// ServiceName returns the systemd service Quadlet generates for a unit file.
+// Only .container files map to a plain service name; other unit types get
+// their type appended, e.g. data.volume becomes data-volume.service.
+func ServiceName(file string) string {
+	ext := filepath.Ext(file)
+	base := strings.TrimSuffix(file, ext)
+	switch ext {
+	case ".container", ".kube":
+		return base + ".service"
+	default:
+		return fmt.Sprintf("%s-%s.service", base, strings.TrimPrefix(ext, "."))
+	}
+}
+
+// StartableUnits returns the services of the workload units in plan. Volume
+// and network units are pulled in by the containers that use them.
+func StartableUnits(plan *Plan) []string {
+	var units []string
+	for _, change := range plan.Changes {
+		if change.Action == ActionRemove {
+			continue
+		}
+		switch filepath.Ext(change.Name) {
+		case ".container", ".kube":
+			units = append(units, ServiceName(change.Name))
+		}
+	}
+	return units
+}
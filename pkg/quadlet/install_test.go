@@ -0,0 +1,101 @@
+package quadlet
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// fakeRunner records commands instead of executing them
+type fakeRunner struct {
+	calls []string
+	err   error
+}
+
+func (f *fakeRunner) Run(name string, args ...string) ([]byte, error) {
+	f.calls = append(f.calls, name+" "+strings.Join(args, " "))
+	if f.err != nil {
+		return []byte("Failed to connect to bus"), f.err
+	}
+	return nil, nil
+}
+
+func TestUnitDir(t *testing.T) {
+	tests := []struct {
+		name     string
+		scope    Scope
+		env      map[string]string
+		expected string
+		wantErr  bool
+	}{
+		{"system", ScopeSystem, nil, "/etc/containers/systemd", false},
+		{"xdg config", ScopeUser, map[string]string{"XDG_CONFIG_HOME": "/cfg", "HOME": "/home/u"}, "/cfg/containers/systemd", false},
+		{"home fallback", ScopeUser, map[string]string{"HOME": "/home/u"}, "/home/u/.config/containers/systemd", false},
+		{"no home", ScopeUser, nil, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, err := UnitDir(tt.scope, func(key string) string { return tt.env[key] })
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UnitDir() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if dir != tt.expected {
+				t.Errorf("UnitDir() = %q, want %q", dir, tt.expected)
+			}
+		})
+	}
+}
+
+func TestInstallerSystemctl(t *testing.T) {
+	runner := &fakeRunner{}
+	inst := &Installer{Scope: ScopeUser, Runner: runner}
+
+	if err := inst.Reload(); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	if err := inst.Start([]string{"web.service", "db.service"}); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	expected := []string{
+		"systemctl --user daemon-reload",
+		"systemctl --user start web.service db.service",
+	}
+	if strings.Join(runner.calls, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected calls %q, got %q", expected, runner.calls)
+	}
+
+	runner = &fakeRunner{}
+	inst = &Installer{Scope: ScopeSystem, Runner: runner}
+	if err := inst.Reload(); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	if runner.calls[0] != "systemctl daemon-reload" {
+		t.Errorf("System scope should not pass --user, got %q", runner.calls[0])
+	}
+
+	inst.Runner = &fakeRunner{err: errors.New("exit status 1")}
+	err := inst.Reload()
+	if err == nil || !strings.Contains(err.Error(), "Failed to connect to bus") {
+		t.Errorf("Expected error with systemctl output, got %v", err)
+	}
+}
+
+func TestServiceName(t *testing.T) {
+	tests := []struct {
+		file     string
+		expected string
+	}{
+		{"web.container", "web.service"},
+		{"app.kube", "app.service"},
+		{"data.volume", "data-volume.service"},
+		{"frontend.network", "frontend-network.service"},
+	}
+
+	for _, tt := range tests {
+		if result := ServiceName(tt.file); result != tt.expected {
+			t.Errorf("ServiceName(%q) = %q, want %q", tt.file, result, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package quadlet
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/kad/compose2podman/internal/types"
+	"github.com/kad/compose2podman/pkg/kube"
+)
+
+// Exit code propagation policies of .kube units, see ExitCodePropagation=
+// in podman-systemd.unit(5)
+const (
+	ExitCodeAll  = "all"
+	ExitCodeAny  = "any"
+	ExitCodeNone = "none"
+)
+
+// ValidateExitCodePropagation checks an exit code propagation policy.
+// Empty leaves the Quadlet default.
+func ValidateExitCodePropagation(policy string) error {
+	switch policy {
+	case "", ExitCodeAll, ExitCodeAny, ExitCodeNone:
+		return nil
+	default:
+		return fmt.Errorf("unknown exit code propagation %q (use all, any or none)", policy)
+	}
+}
+
+// renderKube adds the pod of all services as Kubernetes YAML and the .kube
+// unit running it with podman kube play. Named volumes are created from the
+// claims in the YAML, so no .volume units are written.
+func (g *Generator) renderKube(files map[string]string) error {
+	if err := ValidateExitCodePropagation(g.opts.ExitCodePropagation); err != nil {
+		return err
+	}
+	if g.opts.ImageUnits || g.opts.AuthFile != "" || g.opts.TLSVerify != nil {
+		g.warnf("image units are not generated for a kube pod; image unit, auth file and TLS verify options were ignored")
+	}
+
+	gen := kube.NewGeneratorWithOptions(g.compose, g.opts.PodName, kube.Options{
+		EnvFileMode:        g.opts.EnvFileMode,
+		LookupEnv:          g.opts.LookupEnv,
+		AutoUpdate:         g.opts.AutoUpdate,
+		SeparateConfigMaps: true,
+	})
+	yaml, err := gen.Generate()
+	g.warnings = append(g.warnings, gen.Warnings()...)
+	if err != nil {
+		return err
+	}
+
+	pod := gen.PodName()
+	yamlFile := pod + ".yaml"
+	files[yamlFile] = g.header() + yaml
+
+	configMapFile := ""
+	if configMaps := gen.ConfigMaps(); configMaps != "" {
+		configMapFile = pod + "-configmaps.yaml"
+		files[configMapFile] = g.header() + strings.TrimSuffix(configMaps, "---\n")
+	}
+
+	content, err := g.generateKube(pod, yamlFile, configMapFile)
+	if err != nil {
+		return err
+	}
+	files[pod+".kube"] = content
+	return nil
+}
+
+func (g *Generator) generateKube(pod, yamlFile, configMapFile string) (string, error) {
+	var sb strings.Builder
+
+	sb.WriteString(g.header())
+
+	sb.WriteString("[Unit]\n")
+	sb.WriteString(fmt.Sprintf("Description=%s pod\n", pod))
+	writeRaw(&sb, g.compose.XPodman, "Unit")
+
+	sb.WriteString("\n[Kube]\n")
+	sb.WriteString(fmt.Sprintf("Yaml=%s\n", yamlFile))
+	if configMapFile != "" {
+		sb.WriteString(fmt.Sprintf("ConfigMap=%s\n", configMapFile))
+	}
+
+	// Ports are in the YAML as hostPort as well; PublishPort keeps the host
+	// address and publishes container-only ports like Compose does
+	for _, name := range sortedKeys(g.compose.Services) {
+		for _, port := range g.compose.Services[name].Ports {
+			sb.WriteString(fmt.Sprintf("PublishPort=%s\n", port))
+		}
+	}
+
+	// All containers share the pod's network namespace, which is attached
+	// to every network of any service. Host and none modes are in the YAML.
+	var networks []string
+	for _, name := range sortedKeys(g.compose.Services) {
+		service := g.compose.Services[name]
+		for _, network := range service.NetworksList() {
+			if !slices.Contains(networks, network) {
+				networks = append(networks, network)
+			}
+		}
+	}
+	slices.Sort(networks)
+	for _, network := range networks {
+		sb.WriteString(fmt.Sprintf("Network=%s\n", g.networkRef(network)))
+	}
+
+	autoUpdate, err := g.kubeAutoUpdate()
+	if err != nil {
+		return "", err
+	}
+	for _, policy := range autoUpdate {
+		sb.WriteString(fmt.Sprintf("AutoUpdate=%s\n", policy))
+	}
+
+	if g.opts.ExitCodePropagation != "" {
+		sb.WriteString(fmt.Sprintf("ExitCodePropagation=%s\n", g.opts.ExitCodePropagation))
+	}
+	writeRaw(&sb, g.compose.XPodman, "Kube")
+
+	sb.WriteString("\n[Service]\n")
+	// Allow time to pull the images on first start
+	sb.WriteString("TimeoutStartSec=900\n")
+	writeRaw(&sb, g.compose.XPodman, "Service")
+
+	sb.WriteString("\n[Install]\n")
+	sb.WriteString("WantedBy=default.target\n")
+	writeRaw(&sb, g.compose.XPodman, "Install")
+
+	return sb.String(), nil
+}
+
+// kubeAutoUpdate returns the AutoUpdate= values of the pod: the policy
+// alone when every service uses it, otherwise one <container>/<policy>
+// entry per service with a policy
+func (g *Generator) kubeAutoUpdate() ([]string, error) {
+	var entries []string
+	policies := make(map[string]bool)
+	for _, name := range sortedKeys(g.compose.Services) {
+		service := g.compose.Services[name]
+		policy, err := service.AutoUpdatePolicy(g.opts.AutoUpdate)
+		if err != nil {
+			return nil, fmt.Errorf("service %s: %w", name, err)
+		}
+		policies[policy] = true
+		if policy != "" {
+			entries = append(entries, kubeContainerName(name, service)+"/"+policy)
+		}
+	}
+
+	if len(policies) == 1 && len(entries) > 0 {
+		return []string{sortedKeys(policies)[0]}, nil
+	}
+	return entries, nil
+}
+
+// kubeContainerName returns the name of a service's container in the pod
+func kubeContainerName(name string, service types.Service) string {
+	if service.ContainerName != "" {
+		return service.ContainerName
+	}
+	return name
+}
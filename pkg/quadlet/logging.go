@@ -0,0 +1,23 @@
+package quadlet
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kad/compose2podman/internal/types"
+)
+
+// writeLogging writes the log driver and options of a service
+func (g *Generator) writeLogging(sb *strings.Builder, name string, service types.Service) {
+	logging, errs := service.PodmanLogging()
+	for _, err := range errs {
+		g.warnf("service %s: %v", name, err)
+	}
+	if logging == nil {
+		return
+	}
+	sb.WriteString(fmt.Sprintf("LogDriver=%s\n", logging.Driver))
+	for _, opt := range logging.Options {
+		sb.WriteString(fmt.Sprintf("LogOpt=%s\n", quoteWord(opt)))
+	}
+}
@@ -0,0 +1,63 @@
+package quadlet
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kad/compose2podman/internal/types"
+)
+
+// writeNamespaces writes tty, stdin_open, pid, ipc, cgroup and
+// cgroup_parent, which Quadlet has no keys for
+func (g *Generator) writeNamespaces(sb *strings.Builder, name string, service types.Service) error {
+	if service.Tty {
+		sb.WriteString("PodmanArgs=--tty\n")
+	}
+	if service.StdinOpen {
+		sb.WriteString("PodmanArgs=--interactive\n")
+	}
+
+	for _, ns := range []struct{ field, flag, mode string }{
+		{"pid", "--pid", service.Pid},
+		{"ipc", "--ipc", service.Ipc},
+	} {
+		if ns.mode == "" {
+			continue
+		}
+		mode, err := g.namespaceMode(name, ns.field, ns.mode)
+		if err != nil {
+			return err
+		}
+		sb.WriteString(fmt.Sprintf("PodmanArgs=%s %s\n", ns.flag, mode))
+	}
+
+	switch service.Cgroup {
+	case "":
+	case "host", "private":
+		sb.WriteString(fmt.Sprintf("PodmanArgs=--cgroupns %s\n", service.Cgroup))
+	default:
+		return fmt.Errorf("service %s: invalid cgroup %q (use host or private)", name, service.Cgroup)
+	}
+
+	// Quadlet runs containers with --cgroups=split inside the cgroup of the
+	// unit, which a custom parent cgroup contradicts
+	if service.CgroupParent != "" {
+		sb.WriteString("CgroupsMode=enabled\n")
+		sb.WriteString(fmt.Sprintf("PodmanArgs=--cgroup-parent %s\n", service.CgroupParent))
+	}
+	return nil
+}
+
+// namespaceMode maps a pid or ipc mode to its podman form, replacing
+// service:<name> with the container of that service
+func (g *Generator) namespaceMode(name, field, mode string) (string, error) {
+	owner, ok := strings.CutPrefix(mode, "service:")
+	if !ok {
+		return mode, nil
+	}
+	ownerService, exists := g.compose.Services[owner]
+	if !exists {
+		return "", fmt.Errorf("service %s: %s refers to undefined service %s", name, field, owner)
+	}
+	return "container:" + g.compose.ContainerName(owner, ownerService), nil
+}
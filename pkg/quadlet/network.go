@@ -0,0 +1,159 @@
+package quadlet
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kad/compose2podman/internal/types"
+)
+
+// dockerNetworkOptions maps Docker bridge driver options to their Podman
+// equivalent. Podman rejects unknown bridge options, so other
+// com.docker.network.* options are dropped with a warning.
+var dockerNetworkOptions = map[string]string{
+	"com.docker.network.bridge.name": "InterfaceName",
+	"com.docker.network.driver.mtu":  "mtu",
+}
+
+// podmanNetworkDrivers are the network drivers Podman supports
+var podmanNetworkDrivers = map[string]bool{
+	"bridge":  true,
+	"macvlan": true,
+	"ipvlan":  true,
+}
+
+func (g *Generator) generateNetwork(name string, network types.Network) string {
+	var sb strings.Builder
+
+	sb.WriteString(g.header())
+
+	sb.WriteString("[Unit]\n")
+	sb.WriteString(fmt.Sprintf("Description=%s network\n", name))
+
+	sb.WriteString("\n[Network]\n")
+
+	if network.Driver != "" {
+		if !podmanNetworkDrivers[network.Driver] {
+			g.warnf("network %s: driver %q is not supported by Podman", name, network.Driver)
+		}
+		sb.WriteString(fmt.Sprintf("Driver=%s\n", network.Driver))
+	}
+
+	sb.WriteString(fmt.Sprintf("NetworkName=%s\n", g.compose.NetworkName(name)))
+
+	if network.Internal {
+		sb.WriteString("Internal=true\n")
+	}
+	if network.EnableIPv6 {
+		sb.WriteString("IPv6=true\n")
+	}
+	// attachable has no equivalent: every Podman network accepts new containers
+
+	// IPAM
+	if driver := network.IPAM.Driver; driver != "" && driver != "default" {
+		sb.WriteString(fmt.Sprintf("IPAMDriver=%s\n", driver))
+	}
+	for _, pool := range network.IPAM.Config {
+		if pool.Subnet != "" {
+			sb.WriteString(fmt.Sprintf("Subnet=%s\n", pool.Subnet))
+		}
+		if pool.Gateway != "" {
+			sb.WriteString(fmt.Sprintf("Gateway=%s\n", pool.Gateway))
+		}
+		if pool.IPRange != "" {
+			sb.WriteString(fmt.Sprintf("IPRange=%s\n", pool.IPRange))
+		}
+		if len(pool.AuxAddresses) > 0 {
+			g.warnf("network %s: ipam aux_addresses are not supported by Podman and were dropped", name)
+		}
+	}
+	if len(network.IPAM.Options) > 0 {
+		g.warnf("network %s: ipam options are not supported by Podman and were dropped", name)
+	}
+
+	// Driver options
+	for _, key := range sortedKeys(network.DriverOpts) {
+		val := network.DriverOpts[key]
+		mapped, known := dockerNetworkOptions[key]
+		switch {
+		case mapped == "InterfaceName":
+			sb.WriteString(fmt.Sprintf("InterfaceName=%s\n", val))
+		case known:
+			sb.WriteString(fmt.Sprintf("Options=%s=%s\n", mapped, val))
+		case strings.HasPrefix(key, "com.docker.network."):
+			g.warnf("network %s: driver option %s has no Podman equivalent and was dropped", name, key)
+		default:
+			sb.WriteString(fmt.Sprintf("Options=%s=%s\n", key, val))
+		}
+	}
+
+	// Labels
+	writeLabels(&sb, g.compose.ProjectLabels(), network.Labels)
+	writeRaw(&sb, g.compose.XPodman, "Network")
+
+	sb.WriteString("\n[Install]\n")
+	sb.WriteString("WantedBy=default.target\n")
+
+	return sb.String()
+}
+
+// networkMode returns the Network= value for a service's network_mode, or
+// "" when the service uses regular networks
+func (g *Generator) networkMode(name string, service types.Service) (string, error) {
+	mode := service.NetworkMode
+	if mode == "" || mode == "bridge" {
+		return "", nil
+	}
+	if service.Networks != nil {
+		return "", fmt.Errorf("service %s: network_mode and networks cannot be combined", name)
+	}
+
+	if len(service.Ports) > 0 && !publishesPorts(mode) {
+		g.warnf("service %s: ports cannot be published with network_mode %s and were dropped", name, mode)
+	}
+
+	if owner, ok := service.NetworkModeService(); ok {
+		ownerService, exists := g.compose.Services[owner]
+		if !exists {
+			return "", fmt.Errorf("service %s: network_mode refers to undefined service %s", name, owner)
+		}
+		return "container:" + g.compose.ContainerName(owner, ownerService), nil
+	}
+
+	// host, none, container:<name> and Podman modes like pasta are passed as is
+	return mode, nil
+}
+
+// publishesPorts reports whether a container with the network_mode can
+// publish ports. With host and none there is nothing to publish to, and a
+// container joining another one shares that container's ports.
+func publishesPorts(mode string) bool {
+	return mode != "host" && mode != "none" && !strings.HasPrefix(mode, "container:") && !strings.HasPrefix(mode, "service:")
+}
+
+// networkAttachment returns the Network= value for a service network with
+// its per-network options, e.g. frontend.network:alias=api,ip=10.0.0.5
+func (g *Generator) networkAttachment(service string, attachment types.ServiceNetwork) string {
+	var opts []string
+	for _, alias := range attachment.Aliases {
+		opts = append(opts, "alias="+alias)
+	}
+	if attachment.IPv4Address != "" {
+		opts = append(opts, "ip="+attachment.IPv4Address)
+	}
+	if attachment.IPv6Address != "" {
+		opts = append(opts, "ip6="+attachment.IPv6Address)
+	}
+	if attachment.MacAddress != "" {
+		opts = append(opts, "mac="+attachment.MacAddress)
+	}
+	if len(attachment.LinkLocalIPs) > 0 {
+		g.warnf("service %s: link_local_ips on network %s are not supported by Podman and were dropped", service, attachment.Name)
+	}
+
+	ref := g.networkRef(attachment.Name)
+	if len(opts) == 0 {
+		return ref
+	}
+	return ref + ":" + strings.Join(opts, ",")
+}
@@ -0,0 +1,181 @@
+package quadlet
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Action describes what applying a plan does to a single file
+type Action string
+
+// Actions a plan can contain
+const (
+	ActionCreate    Action = "create"
+	ActionUpdate    Action = "update"
+	ActionUnchanged Action = "unchanged"
+	ActionRemove    Action = "remove"
+)
+
+// FileChange describes the planned change for one file in the output directory
+type FileChange struct {
+	Name   string
+	Action Action
+	Old    string // current content on disk, empty for new files
+	New    string // generated content, empty for removed files
+}
+
+// Plan lists the changes needed to bring the output directory in line with
+// the generated files, sorted by file name
+type Plan struct {
+	Dir     string
+	Changes []FileChange
+}
+
+// Plan renders all files and compares them with the output directory.
+// Files that carry the ManagedHeader but are no longer generated are
+// reported with ActionRemove; files written by anyone else are ignored.
+func (g *Generator) Plan() (*Plan, error) {
+	files, err := g.Render()
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &Plan{Dir: g.outputDir}
+	for name, content := range files {
+		change := FileChange{Name: name, Action: ActionCreate, New: content}
+		old, err := readFile(filepath.Join(g.outputDir, name))
+		switch {
+		case err == nil && old == content:
+			change.Action = ActionUnchanged
+			change.Old = old
+		case err == nil:
+			change.Action = ActionUpdate
+			change.Old = old
+		case !errors.Is(err, fs.ErrNotExist):
+			return nil, fmt.Errorf("failed to read existing file: %w", err)
+		}
+		plan.Changes = append(plan.Changes, change)
+	}
+
+	stale, err := g.staleFiles(files)
+	if err != nil {
+		return nil, err
+	}
+	plan.Changes = append(plan.Changes, stale...)
+
+	sort.Slice(plan.Changes, func(i, j int) bool {
+		return plan.Changes[i].Name < plan.Changes[j].Name
+	})
+	return plan, nil
+}
+
+// staleFiles returns previously generated files that are not part of files
+func (g *Generator) staleFiles(files map[string]string) ([]FileChange, error) {
+	entries, err := os.ReadDir(g.outputDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read output directory: %w", err)
+	}
+
+	var stale []FileChange
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if _, ok := files[entry.Name()]; ok {
+			continue
+		}
+		old, err := readFile(filepath.Join(g.outputDir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read existing file: %w", err)
+		}
+		if g.isManaged(old) {
+			stale = append(stale, FileChange{Name: entry.Name(), Action: ActionRemove, Old: old})
+		}
+	}
+	return stale, nil
+}
+
+// Apply writes created and updated files from plan. Stale files are only
+// removed when prune is set.
+func (g *Generator) Apply(plan *Plan, prune bool) error {
+	// Create output directory with standard permissions
+	//nolint:gosec // G301: Standard directory permissions for systemd unit files
+	if err := os.MkdirAll(plan.Dir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	for _, change := range plan.Changes {
+		path := filepath.Join(plan.Dir, change.Name)
+		switch change.Action {
+		case ActionCreate, ActionUpdate:
+			//nolint:gosec // G306: Unit files should be readable by others
+			if err := os.WriteFile(path, []byte(change.New), 0644); err != nil {
+				return fmt.Errorf("failed to write %s: %w", change.Name, err)
+			}
+		case ActionRemove:
+			if !prune {
+				continue
+			}
+			if err := os.Remove(path); err != nil {
+				return fmt.Errorf("failed to remove %s: %w", change.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Diff returns a unified diff of every changed file in the plan. Stale
+// files are only shown as removed when prune is set, matching Apply.
+func (p *Plan) Diff(prune bool) string {
+	var sb strings.Builder
+	for _, change := range p.Changes {
+		if change.Action == ActionUnchanged || (change.Action == ActionRemove && !prune) {
+			continue
+		}
+		path := filepath.Join(p.Dir, change.Name)
+		sb.WriteString(UnifiedDiff(path, change.Old, change.New))
+	}
+	return sb.String()
+}
+
+// Files returns the generated content of every file in the plan, keyed by name
+func (p *Plan) Files() map[string]string {
+	files := make(map[string]string)
+	for _, change := range p.Changes {
+		if change.Action != ActionRemove {
+			files[change.Name] = change.New
+		}
+	}
+	return files
+}
+
+// isManaged reports whether content was written by compose2podman for the
+// same project as this generator
+func (g *Generator) isManaged(content string) bool {
+	rest, ok := strings.CutPrefix(content, ManagedHeader+"\n")
+	if !ok {
+		return false
+	}
+	project := ""
+	if line, _, _ := strings.Cut(rest, "\n"); strings.HasPrefix(line, projectHeaderPrefix) {
+		project = strings.TrimPrefix(line, projectHeaderPrefix)
+	}
+	return project == g.compose.Name
+}
+
+// nolint:gosec // G304: Paths are built from the user-selected output directory
+func readFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
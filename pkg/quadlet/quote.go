@@ -0,0 +1,16 @@
+package quadlet
+
+import "strings"
+
+// quoteWord quotes a single word of a space-separated systemd value such as
+// Environment=KEY=value, so that values with whitespace, quotes or newlines
+// survive unit file parsing. Percent signs are doubled because systemd
+// expands %-specifiers in the generated service.
+func quoteWord(word string) string {
+	word = strings.ReplaceAll(word, "%", "%%")
+	if word != "" && !strings.ContainsAny(word, " \t\n\r\"'\\") {
+		return word
+	}
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`)
+	return `"` + replacer.Replace(word) + `"`
+}
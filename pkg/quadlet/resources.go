@@ -0,0 +1,40 @@
+package quadlet
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kad/compose2podman/internal/types"
+)
+
+// writeResources writes tmpfs mounts, shm_size, ulimits and sysctls
+func (g *Generator) writeResources(sb *strings.Builder, name string, service types.Service) error {
+	for _, tmpfs := range service.TmpfsList() {
+		if tmpfs.Options == "" {
+			sb.WriteString(fmt.Sprintf("Tmpfs=%s\n", tmpfs.Path))
+		} else {
+			sb.WriteString(fmt.Sprintf("Tmpfs=%s:%s\n", tmpfs.Path, tmpfs.Options))
+		}
+	}
+
+	if service.ShmSize != "" {
+		if _, err := types.ParseByteSize(service.ShmSize); err != nil {
+			return fmt.Errorf("service %s: shm_size: %w", name, err)
+		}
+		sb.WriteString(fmt.Sprintf("ShmSize=%s\n", service.ShmSize))
+	}
+
+	limits, err := service.UlimitsList()
+	if err != nil {
+		return fmt.Errorf("service %s: %w", name, err)
+	}
+	for _, limit := range limits {
+		sb.WriteString(fmt.Sprintf("Ulimit=%s\n", limit))
+	}
+
+	sysctls := service.SysctlsMap()
+	for _, key := range sortedKeys(sysctls) {
+		sb.WriteString(fmt.Sprintf("Sysctl=%s\n", quoteWord(key+"="+sysctls[key])))
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package quadlet
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kad/compose2podman/internal/types"
+)
+
+// systemdRestart maps Compose restart policies to systemd Restart= values.
+// systemd does not remember a manual stop across reboots, so unless-stopped
+// behaves like always.
+var systemdRestart = map[string]string{
+	types.RestartNo:            "no",
+	types.RestartAlways:        "always",
+	types.RestartOnFailure:     "on-failure",
+	types.RestartUnlessStopped: "always",
+}
+
+// writeStartLimit writes the [Unit] start rate limit enforcing the maximum
+// number of restarts. systemd counts the initial start too, so the burst
+// is one more than the number of restarts; without a window the limit
+// applies for the lifetime of the unit.
+func writeStartLimit(sb *strings.Builder, restart types.RestartSettings) {
+	if restart.MaxAttempts == 0 || restart.Policy == types.RestartNo {
+		return
+	}
+	sb.WriteString(fmt.Sprintf("StartLimitBurst=%d\n", restart.MaxAttempts+1))
+	if restart.Window > 0 {
+		sb.WriteString(fmt.Sprintf("StartLimitIntervalSec=%s\n", systemdSeconds(restart.Window)))
+	} else {
+		sb.WriteString("StartLimitIntervalSec=infinity\n")
+	}
+}
+
+// writeRestart writes the [Service] restart settings
+func writeRestart(sb *strings.Builder, restart types.RestartSettings) {
+	sb.WriteString(fmt.Sprintf("Restart=%s\n", systemdRestart[restart.Policy]))
+	if restart.Delay > 0 && restart.Policy != types.RestartNo {
+		sb.WriteString(fmt.Sprintf("RestartSec=%s\n", systemdSeconds(restart.Delay)))
+	}
+}
+
+// systemdSeconds formats a duration as a systemd time span in seconds
+func systemdSeconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', -1, 64)
+}
@@ -0,0 +1,83 @@
+package quadlet
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kad/compose2podman/internal/types"
+)
+
+// writeSecurity writes privileged, cap_add/cap_drop, read_only,
+// security_opt, userns_mode and group_add. Options without a Quadlet key are
+// passed to podman with PodmanArgs=.
+func (g *Generator) writeSecurity(sb *strings.Builder, service types.Service) {
+	opts := service.SecurityOptions()
+
+	// Quadlet has no Privileged= key; --privileged grants all capabilities
+	// and devices and disables confinement, which no set of keys reproduces
+	if service.Privileged {
+		sb.WriteString("PodmanArgs=--privileged\n")
+	}
+
+	add, drop := service.Capabilities()
+	for _, cap := range add {
+		sb.WriteString(fmt.Sprintf("AddCapability=%s\n", cap))
+	}
+	for _, cap := range drop {
+		sb.WriteString(fmt.Sprintf("DropCapability=%s\n", cap))
+	}
+
+	if service.ReadOnly {
+		sb.WriteString("ReadOnly=true\n")
+	}
+	if opts.NoNewPrivileges {
+		sb.WriteString("NoNewPrivileges=true\n")
+	}
+	if opts.Seccomp != "" {
+		profile := opts.Seccomp
+		if profile != "unconfined" {
+			profile = g.compose.ResolvePath(profile)
+		}
+		sb.WriteString(fmt.Sprintf("SeccompProfile=%s\n", profile))
+	}
+
+	// SELinux labels; privileged already disables labeling
+	if opts.LabelDisable && !service.Privileged {
+		sb.WriteString("SecurityLabelDisable=true\n")
+	}
+	if opts.LabelNested {
+		sb.WriteString("SecurityLabelNested=true\n")
+	}
+	if opts.LabelType != "" {
+		sb.WriteString(fmt.Sprintf("SecurityLabelType=%s\n", opts.LabelType))
+	}
+	if opts.LabelLevel != "" {
+		sb.WriteString(fmt.Sprintf("SecurityLabelLevel=%s\n", opts.LabelLevel))
+	}
+	if opts.LabelUser != "" {
+		sb.WriteString(fmt.Sprintf("PodmanArgs=--security-opt label=user:%s\n", opts.LabelUser))
+	}
+	if opts.LabelRole != "" {
+		sb.WriteString(fmt.Sprintf("PodmanArgs=--security-opt label=role:%s\n", opts.LabelRole))
+	}
+
+	if opts.AppArmor != "" {
+		sb.WriteString(fmt.Sprintf("PodmanArgs=--security-opt apparmor=%s\n", opts.AppArmor))
+	}
+	for _, path := range opts.Mask {
+		sb.WriteString(fmt.Sprintf("Mask=%s\n", path))
+	}
+	for _, path := range opts.Unmask {
+		sb.WriteString(fmt.Sprintf("Unmask=%s\n", path))
+	}
+	for _, opt := range opts.Other {
+		sb.WriteString(fmt.Sprintf("PodmanArgs=--security-opt %s\n", quoteWord(opt)))
+	}
+
+	if userns := service.UserNSMode(); userns != "" {
+		sb.WriteString(fmt.Sprintf("UserNS=%s\n", userns))
+	}
+	for _, group := range service.GroupAdd {
+		sb.WriteString(fmt.Sprintf("GroupAdd=%s\n", group))
+	}
+}
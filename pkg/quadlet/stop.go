@@ -0,0 +1,34 @@
+package quadlet
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/kad/compose2podman/internal/types"
+)
+
+// stopTimeoutMargin is added to StopTimeout for TimeoutStopSec, so systemd
+// does not kill the service while podman is still waiting for the container
+// to stop and then killing it itself
+const stopTimeoutMargin = 10
+
+// writeStop writes stop_signal, stop_grace_period and init to [Container]
+func writeStop(sb *strings.Builder, service types.Service, grace time.Duration, graceSet bool) {
+	if service.StopSignal != "" {
+		sb.WriteString(fmt.Sprintf("StopSignal=%s\n", service.StopSignal))
+	}
+	if graceSet {
+		sb.WriteString(fmt.Sprintf("StopTimeout=%d\n", stopSeconds(grace)))
+	}
+	if service.Init != nil {
+		sb.WriteString(fmt.Sprintf("RunInit=%t\n", *service.Init))
+	}
+}
+
+// stopSeconds rounds a stop grace period up to whole seconds, the unit of
+// podman --stop-timeout
+func stopSeconds(d time.Duration) int64 {
+	return int64(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,189 @@
+package quadlet
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// DefaultQuadletPath is where Podman installs the Quadlet systemd generator
+const DefaultQuadletPath = "/usr/libexec/podman/quadlet"
+
+// Issue is a problem found in a single Quadlet file. File is empty for
+// problems that could not be attributed to a file.
+type Issue struct {
+	File    string
+	Message string
+}
+
+func (i Issue) String() string {
+	if i.File == "" {
+		return i.Message
+	}
+	return fmt.Sprintf("%s: %s", i.File, i.Message)
+}
+
+// ErrQuadletNotFound is returned by VerifyDir when the Quadlet binary is missing
+var ErrQuadletNotFound = errors.New("quadlet binary not found")
+
+// quadletErrorPattern matches the file name in Quadlet conversion errors,
+// e.g. `converting "web.container": unsupported key ...` (Podman 5) or
+// `Error converting 'web.container', ignoring: ...` (Podman 4)
+var quadletErrorPattern = regexp.MustCompile(`converting ["']([^"']+)["'][:,]\s*(?:ignoring:\s*)?(.*)`)
+
+// VerifyDir runs the Quadlet generator in dry-run mode against dir and
+// returns the errors it reports, grouped per file. The runner is invoked
+// through env(1) so only dir is used as the unit search path.
+func VerifyDir(runner Runner, quadletPath, dir string, scope Scope) ([]Issue, error) {
+	if quadletPath == "" {
+		quadletPath = DefaultQuadletPath
+	}
+	if _, err := exec.LookPath(quadletPath); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrQuadletNotFound, quadletPath)
+	}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve output directory: %w", err)
+	}
+
+	args := []string{"QUADLET_UNIT_DIRS=" + absDir, quadletPath, "-dryrun"}
+	if scope == ScopeUser {
+		args = append(args, "-user")
+	}
+	out, runErr := runner.Run("env", args...)
+
+	issues := parseQuadletOutput(string(out))
+	if runErr != nil && len(issues) == 0 {
+		return nil, fmt.Errorf("quadlet dry-run failed: %w: %s", runErr, strings.TrimSpace(string(out)))
+	}
+	return issues, nil
+}
+
+// parseQuadletOutput extracts conversion errors from quadlet -dryrun output.
+// Generated unit contents are printed as well and are skipped.
+func parseQuadletOutput(out string) []Issue {
+	var issues []Issue
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if m := quadletErrorPattern.FindStringSubmatch(line); m != nil {
+			issues = append(issues, Issue{File: filepath.Base(m[1]), Message: strings.TrimSpace(m[2])})
+		}
+	}
+	return issues
+}
+
+// quadletSections maps each Quadlet file extension to the section Quadlet
+// parses for it and the keys it accepts there (podman-systemd.unit(5)).
+// Systemd sections like [Unit], [Service] and [Install] are passed through
+// to systemd and are not checked.
+var quadletSections = map[string]struct {
+	section  string
+	required []string
+	keys     []string
+}{
+	".container": {"Container", []string{"Image"}, []string{
+		"AddCapability", "AddDevice", "AddHost", "Annotation", "AutoUpdate", "CgroupsMode",
+		"ContainerName", "ContainersConfModule", "DNS", "DNSOption", "DNSSearch",
+		"DropCapability", "Entrypoint", "Environment", "EnvironmentFile", "EnvironmentHost",
+		"Exec", "ExposeHostPort", "GIDMap", "GlobalArgs", "Group", "GroupAdd", "HealthCmd",
+		"HealthInterval", "HealthLogDestination", "HealthMaxLogCount", "HealthMaxLogSize",
+		"HealthOnFailure", "HealthRetries", "HealthStartPeriod", "HealthStartupCmd",
+		"HealthStartupInterval", "HealthStartupRetries", "HealthStartupSuccess",
+		"HealthStartupTimeout", "HealthTimeout", "HostName", "HttpProxy", "Image", "IP", "IP6",
+		"Label", "LogDriver", "LogOpt", "Mask", "Memory", "Mount", "Network", "NetworkAlias",
+		"NoNewPrivileges", "Notify", "PidsLimit", "Pod", "PodmanArgs", "PublishPort", "Pull",
+		"ReadOnly", "ReadOnlyTmpfs", "ReloadCmd", "ReloadSignal", "Retry", "RetryDelay",
+		"Rootfs", "RunInit", "SeccompProfile", "Secret", "SecurityLabelDisable",
+		"SecurityLabelFileType", "SecurityLabelLevel", "SecurityLabelNested",
+		"SecurityLabelType", "ShmSize", "StartWithPod", "StopSignal", "StopTimeout",
+		"SubGIDMap", "SubUIDMap", "Sysctl", "Timezone", "Tmpfs", "UIDMap", "Ulimit", "Unmask",
+		"User", "UserNS", "Volume", "WorkingDir",
+	}},
+	".volume": {"Volume", nil, []string{
+		"ContainersConfModule", "Copy", "Device", "Driver", "GlobalArgs", "Group", "Image",
+		"Label", "Options", "PodmanArgs", "Type", "User", "VolumeName",
+	}},
+	".network": {"Network", nil, []string{
+		"ContainersConfModule", "DisableDNS", "DNS", "Driver", "Gateway", "GlobalArgs",
+		"InterfaceName", "Internal", "IPAMDriver", "IPRange", "IPv6", "Label",
+		"NetworkDeleteOnStop", "NetworkName", "Options", "PodmanArgs", "Subnet",
+	}},
+	".kube": {"Kube", []string{"Yaml"}, []string{
+		"AutoUpdate", "ConfigMap", "ContainersConfModule", "ExitCodePropagation", "GlobalArgs",
+		"KubeDownForce", "LogDriver", "LogOpt", "Network", "PodmanArgs", "PublishPort",
+		"SetWorkingDirectory", "UserNS", "Yaml",
+	}},
+	".image": {"Image", []string{"Image"}, []string{
+		"AllTags", "Arch", "AuthFile", "CertDir", "ContainersConfModule", "Creds",
+		"DecryptionKey", "GlobalArgs", "Image", "ImageTag", "OS", "PodmanArgs", "Policy", "Retry",
+		"RetryDelay", "TLSVerify", "Variant",
+	}},
+	".pod": {"Pod", nil, []string{
+		"AddHost", "ContainersConfModule", "DNS", "DNSOption", "DNSSearch", "GIDMap",
+		"GlobalArgs", "HostName", "IP", "IP6", "Label", "Network", "NetworkAlias", "PodmanArgs",
+		"PodName", "PublishPort", "ServiceName", "ShmSize", "SubGIDMap", "SubUIDMap", "UIDMap",
+		"UserNS", "Volume",
+	}},
+}
+
+// Validate checks rendered Quadlet files against the keys Quadlet accepts
+// in each unit type's section. It is used when the Quadlet binary is not
+// available to run a real dry-run.
+func Validate(files map[string]string) []Issue {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var issues []Issue
+	for _, name := range names {
+		spec, ok := quadletSections[filepath.Ext(name)]
+		if !ok {
+			continue
+		}
+		known := make(map[string]bool, len(spec.keys))
+		for _, key := range spec.keys {
+			known[key] = true
+		}
+
+		seen := make(map[string]bool)
+		section := ""
+		for n, line := range strings.Split(files[name], "\n") {
+			line = strings.TrimSpace(line)
+			switch {
+			case line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";"):
+				continue
+			case strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]"):
+				section = line[1 : len(line)-1]
+				continue
+			case section != spec.section:
+				continue
+			}
+
+			key, _, found := strings.Cut(line, "=")
+			key = strings.TrimSpace(key)
+			if !found {
+				issues = append(issues, Issue{File: name, Message: fmt.Sprintf("line %d: expected Key=Value in [%s]", n+1, section)})
+				continue
+			}
+			if !known[key] {
+				issues = append(issues, Issue{File: name, Message: fmt.Sprintf("line %d: unsupported key '%s' in group '%s'", n+1, key, section)})
+			}
+			seen[key] = true
+		}
+
+		for _, key := range spec.required {
+			if !seen[key] {
+				issues = append(issues, Issue{File: name, Message: fmt.Sprintf("missing required key '%s' in group '%s'", key, spec.section)})
+			}
+		}
+	}
+	return issues
+}
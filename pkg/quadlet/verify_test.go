@@ -0,0 +1,79 @@
+package quadlet
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	files := map[string]string{
+		"web.container": "[Unit]\nDescription=web\n\n[Container]\nImage=nginx\nPublishPort=80:80\nBogusKey=1\n\n[Service]\nRestart=always\n",
+		"db.container":  "[Container]\nContainerName=db\n",
+		"data.volume":   "[Volume]\nDriver=local\n",
+		"README.md":     "[Container]\nWhatever=1\n",
+	}
+
+	issues := Validate(files)
+
+	expected := []string{
+		"db.container: missing required key 'Image' in group 'Container'",
+		"web.container: line 7: unsupported key 'BogusKey' in group 'Container'",
+	}
+	if len(issues) != len(expected) {
+		t.Fatalf("Expected %d issues, got %v", len(expected), issues)
+	}
+	for i, issue := range issues {
+		if issue.String() != expected[i] {
+			t.Errorf("Issue %d: expected %q, got %q", i, expected[i], issue.String())
+		}
+	}
+}
+
+func TestParseQuadletOutput(t *testing.T) {
+	out := `quadlet-generator[42]: converting "web.container": unsupported key 'BogusKey' in group 'Container' in /tmp/q/web.container
+---web.service---
+[Unit]
+Description=web
+quadlet-generator[42]: Error converting 'db.volume', ignoring: invalid key
+`
+	issues := parseQuadletOutput(out)
+	if len(issues) != 2 {
+		t.Fatalf("Expected 2 issues, got %v", issues)
+	}
+	if issues[0].File != "web.container" || !strings.HasPrefix(issues[0].Message, "unsupported key 'BogusKey'") {
+		t.Errorf("Unexpected first issue: %v", issues[0])
+	}
+	if issues[1].File != "db.volume" || issues[1].Message != "invalid key" {
+		t.Errorf("Unexpected second issue: %v", issues[1])
+	}
+}
+
+func TestVerifyDir(t *testing.T) {
+	dir := t.TempDir()
+	bin := filepath.Join(dir, "quadlet")
+	//nolint:gosec // G306: The fake binary must be executable
+	if err := os.WriteFile(bin, []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	runner := &fakeRunner{}
+	issues, err := VerifyDir(runner, bin, dir, ScopeUser)
+	if err != nil {
+		t.Fatalf("VerifyDir failed: %v", err)
+	}
+	if len(issues) != 0 {
+		t.Errorf("Expected no issues, got %v", issues)
+	}
+	expected := "env QUADLET_UNIT_DIRS=" + dir + " " + bin + " -dryrun -user"
+	if len(runner.calls) != 1 || runner.calls[0] != expected {
+		t.Errorf("Expected call %q, got %q", expected, runner.calls)
+	}
+
+	_, err = VerifyDir(runner, filepath.Join(dir, "missing"), dir, ScopeUser)
+	if !errors.Is(err, ErrQuadletNotFound) {
+		t.Errorf("Expected ErrQuadletNotFound, got %v", err)
+	}
+}
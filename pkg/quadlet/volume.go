@@ -0,0 +1,89 @@
+package quadlet
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/kad/compose2podman/internal/types"
+)
+
+func (g *Generator) generateVolume(name string, volume types.Volume) string {
+	var sb strings.Builder
+
+	sb.WriteString(g.header())
+
+	sb.WriteString("[Unit]\n")
+	sb.WriteString(fmt.Sprintf("Description=%s volume\n", name))
+
+	sb.WriteString("\n[Volume]\n")
+
+	if volume.Driver != "" && volume.Driver != "local" {
+		sb.WriteString(fmt.Sprintf("Driver=%s\n", volume.Driver))
+	}
+
+	sb.WriteString(fmt.Sprintf("VolumeName=%s\n", g.compose.VolumeName(name)))
+
+	// Driver options of the local driver have dedicated keys; anything
+	// else is passed to podman volume create as is
+	for _, key := range sortedKeys(volume.DriverOpts) {
+		val := volume.DriverOpts[key]
+		switch key {
+		case "type":
+			sb.WriteString(fmt.Sprintf("Type=%s\n", val))
+		case "o":
+			sb.WriteString(fmt.Sprintf("Options=%s\n", val))
+		case "device":
+			if isBindOption(volume.DriverOpts["o"]) && !strings.HasPrefix(val, "/") {
+				g.warnf("volume %s: bind device %s should be an absolute path", name, val)
+			}
+			sb.WriteString(fmt.Sprintf("Device=%s\n", val))
+		case "uid":
+			sb.WriteString(fmt.Sprintf("User=%s\n", val))
+		case "gid":
+			sb.WriteString(fmt.Sprintf("Group=%s\n", val))
+		default:
+			sb.WriteString(fmt.Sprintf("PodmanArgs=--opt %s=%s\n", key, val))
+		}
+	}
+
+	if g.volumeNoCopy(name) {
+		sb.WriteString("Copy=false\n")
+	}
+
+	// Labels
+	writeLabels(&sb, g.compose.ProjectLabels(), volume.Labels)
+	writeRaw(&sb, g.compose.XPodman, "Volume")
+
+	sb.WriteString("\n[Install]\n")
+	sb.WriteString("WantedBy=default.target\n")
+
+	return sb.String()
+}
+
+// volumeNoCopy reports whether any service mounts the volume with the
+// nocopy option, which disables copying image content into the volume
+func (g *Generator) volumeNoCopy(name string) bool {
+	for _, service := range g.compose.Services {
+		for _, vol := range service.Volumes {
+			parts := strings.Split(vol, ":")
+			if len(parts) < 3 || parts[0] != name {
+				continue
+			}
+			if slices.Contains(strings.Split(parts[2], ","), "nocopy") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isBindOption reports whether mount options o contain bind
+func isBindOption(o string) bool {
+	for _, opt := range strings.Split(o, ",") {
+		if opt == "bind" || opt == "rbind" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+package quadlet
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kad/compose2podman/internal/types"
+)
+
+// writeXPodman writes the x-podman settings of a service that have no
+// Compose attribute: Pod=, PodmanArgs=, GlobalArgs= and Notify=
+func writeXPodman(sb *strings.Builder, service types.Service) {
+	x := service.XPodman
+	if x == nil {
+		return
+	}
+	if x.Pod != "" {
+		pod := x.Pod
+		if !strings.HasSuffix(pod, ".pod") {
+			pod += ".pod"
+		}
+		sb.WriteString(fmt.Sprintf("Pod=%s\n", pod))
+	}
+	for _, arg := range x.PodmanArgs {
+		sb.WriteString(fmt.Sprintf("PodmanArgs=%s\n", arg))
+	}
+	for _, arg := range x.GlobalArgs {
+		sb.WriteString(fmt.Sprintf("GlobalArgs=%s\n", arg))
+	}
+	if x.Notify != "" {
+		sb.WriteString(fmt.Sprintf("Notify=%s\n", x.Notify))
+	}
+}
+
+// writeRaw writes the raw x-podman.quadlet keys of a section
+func writeRaw(sb *strings.Builder, x *types.XPodman, section string) {
+	for _, entry := range x.QuadletEntries(section) {
+		sb.WriteString(entry + "\n")
+	}
+}
+
+// relabelVolume adds the x-podman.relabel mode to a bind mount that does
+// not set one. Named volumes are left alone.
+func relabelVolume(vol, relabel string) string {
+	if relabel == "" || !isBindSource(vol) {
+		return vol
+	}
+	parts := strings.Split(vol, ":")
+	switch len(parts) {
+	case 2:
+		return vol + ":" + relabel
+	case 3:
+		for _, opt := range strings.Split(parts[2], ",") {
+			if opt == "z" || opt == "Z" {
+				return vol
+			}
+		}
+		return vol + "," + relabel
+	default:
+		return vol
+	}
+}
+
+// isBindSource reports whether a service volume mounts a host path
+func isBindSource(vol string) bool {
+	return strings.HasPrefix(vol, "/") || strings.HasPrefix(vol, ".") || strings.HasPrefix(vol, "~")
+}
+
+// portHandlerNetwork returns the network mode with the x-podman
+// port-handler applied. The handler is an option of slirp4netns, so it
+// applies to an explicit slirp4netns mode, or replaces the default network
+// with a warning.
+func (g *Generator) portHandlerNetwork(name string, service types.Service, network string) string {
+	if service.XPodman == nil || service.XPodman.PortHandler == "" {
+		return network
+	}
+	handler := "port_handler=" + service.XPodman.PortHandler
+	switch {
+	case network == "" && len(service.NetworksList()) == 0:
+		g.warnf("service %s: x-podman.port-handler moves the container from Podman's default network to slirp4netns; set network_mode: slirp4netns to make this explicit", name)
+		return "slirp4netns:" + handler
+	case network == "slirp4netns":
+		return network + ":" + handler
+	case strings.HasPrefix(network, "slirp4netns:"):
+		return network + "," + handler
+	default:
+		g.warnf("service %s: x-podman.port-handler needs the slirp4netns network and was ignored", name)
+		return network
+	}
+}